@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"auth-service/auth"
+	"auth-service/models"
+	"auth-service/pkg"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// @Summary Register OAuth client
+// @Description Register an application for the OAuth endpoints. Applications signing users in use the authorization_code and refresh_token grants with the openid, profile and email scopes. Service clients use only the client_credentials grant, must be confidential and get the users:read and users:admin scopes. Confidential clients get a client_secret, it is shown only in this response
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Client body models.CreateOAuthClientRequest true "OAuth client"
+// @Success 201 {object} models.OAuthClientCredentials
+// @Failure 400 {object} models.Errors
+// @Failure 401 {object} models.Errors
+// @Failure 403 {object} models.Errors
+// @Failure 500 {object} models.Errors
+// @Router /api/v1/admin/oauth/clients [post]
+func (h *Handler) CreateOAuthClientHandler(ctx *gin.Context) {
+	var req models.CreateOAuthClientRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if len(req.GrantTypes) == 0 {
+		req.GrantTypes = []string{"authorization_code", "refresh_token"}
+	}
+	if req.Scopes == nil {
+		req.Scopes = []string{}
+	}
+
+	if message := checkClientRegistration(&req); message != "" {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: message,
+		})
+		return
+	}
+
+	// Redirect URI to'liq (absolute) bo'lishi va fragment saqlamasligi kerak (RFC 6749, 3.1.2)
+	for _, redirectURI := range req.RedirectURIs {
+		u, err := url.Parse(redirectURI)
+		if err != nil || !u.IsAbs() || u.Fragment != "" {
+			ctx.JSON(http.StatusBadRequest, models.Errors{
+				Message: "invalid redirect uri: " + redirectURI,
+			})
+			return
+		}
+	}
+
+	clientID, err := pkg.GenerateRandomString(16)
+	if err != nil {
+		h.Logger.Error("Error generating client id", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error register client",
+		})
+		return
+	}
+
+	client := models.OAuthClient{
+		ClientID:     clientID,
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       req.Scopes,
+		GrantTypes:   req.GrantTypes,
+	}
+	if client.RedirectURIs == nil {
+		client.RedirectURIs = []string{}
+	}
+
+	var secret string
+	if req.Confidential {
+		secret, err = pkg.GenerateRandomString(32)
+		if err != nil {
+			h.Logger.Error("Error generating client secret", slog.String("error", err.Error()))
+			ctx.JSON(http.StatusInternalServerError, models.Errors{
+				Message: "Error register client",
+			})
+			return
+		}
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+		if err != nil {
+			h.Logger.Error("Error hashing client secret", slog.String("error", err.Error()))
+			ctx.JSON(http.StatusInternalServerError, models.Errors{
+				Message: "Error register client",
+			})
+			return
+		}
+		client.SecretHash = string(hash)
+	}
+
+	created, err := h.UserRepo.CreateOAuthClient(client)
+	if err != nil {
+		h.Logger.Error("Error register client", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error register client",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.OAuthClientCredentials{
+		OAuthClient:  *created,
+		ClientSecret: secret,
+	})
+}
+
+// checkClientRegistration returns why req cannot be registered, or "" if it
+// can. Service clients never act for a user, so they cannot mix grants and
+// user scopes are not given to them, nor client scopes to applications.
+func checkClientRegistration(req *models.CreateOAuthClientRequest) string {
+	if containsAll(req.GrantTypes, []string{"client_credentials"}) {
+		if len(req.GrantTypes) != 1 {
+			return "client_credentials cannot be combined with other grant types"
+		}
+		if !req.Confidential {
+			return "client_credentials clients must be confidential"
+		}
+		if !containsAll(auth.ClientScopes, req.Scopes) {
+			return "client_credentials clients may only have the scopes " + strings.Join(auth.ClientScopes, ", ")
+		}
+		return ""
+	}
+
+	if !containsAll([]string{"authorization_code", "refresh_token"}, req.GrantTypes) {
+		return "unsupported grant type"
+	}
+	if len(req.RedirectURIs) == 0 {
+		return "redirect_uris are required"
+	}
+	if !containsAll(auth.UserScopes, req.Scopes) {
+		return "applications may only have the scopes " + strings.Join(auth.UserScopes, ", ")
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"auth-service/config"
+	"auth-service/models"
+	"auth-service/pkg/mailer"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// publicLink builds a link for an email on PUBLIC_BASE_URL, the address
+// users reach the service and the web app on.
+func publicLink(path string, query url.Values) string {
+	link := strings.TrimRight(config.Load().PUBLIC_BASE_URL, "/") + path
+	if len(query) > 0 {
+		link += "?" + query.Encode()
+	}
+	return link
+}
+
+// composeEmail renders a templated email in the language of the request,
+// falling back to MAIL_DEFAULT_LOCALE. The result is ready for the outbox.
+func composeEmail(ctx *gin.Context, to, name string, data mailer.Data) (*models.OutboxEmail, error) {
+	locale := mailer.Locale(ctx.GetHeader("Accept-Language"), config.Load().MAIL_DEFAULT_LOCALE)
+
+	msg, err := mailer.Render(name, locale, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OutboxEmail{
+		Recipient: to,
+		Subject:   msg.Subject,
+		TextBody:  msg.Text,
+		HTMLBody:  msg.HTML,
+	}, nil
+}
+
+// queueEmail puts a templated email in the outbox. The outbox worker sends
+// it, so a slow mail server never holds up the request.
+func (h *Handler) queueEmail(ctx *gin.Context, to, name string, data mailer.Data) error {
+	email, err := composeEmail(ctx, to, name, data)
+	if err != nil {
+		return err
+	}
+
+	return h.UserRepo.EnqueueEmail(email)
+}
@@ -1,21 +1,52 @@
 package handler
 
 import (
+	"auth-service/auth"
+	"auth-service/config"
+	"auth-service/pkg/password"
+	"auth-service/ratelimit"
 	"auth-service/storage/postgres"
 	"auth-service/storage/redis"
+	"fmt"
 	"log/slog"
 )
 
+// minTOTPKeyLength is the shortest TOTP_ENCRYPTION_KEY accepted, as many
+// characters as a 256-bit key has bytes.
+const minTOTPKeyLength = 32
+
 type Handler struct {
 	UserRepo    *postgres.UserRepo
 	RedisClient *redis.RedisClient
 	Logger      *slog.Logger
+	Verifier    *auth.Verifier
+	Limiter     *ratelimit.Limiter
+	Hasher      password.Hasher
+	Policy      password.Policy
+	// TOTPKey encrypts the TOTP secrets stored in the database.
+	TOTPKey string
 }
 
-func NewHandler(user *postgres.UserRepo, logger *slog.Logger, client *redis.RedisClient) *Handler {
+// NewHandler builds the handler from cfg. It fails if the password hashing
+// costs in cfg are not usable or the TOTP encryption key is missing.
+func NewHandler(cfg config.Config, user *postgres.UserRepo, logger *slog.Logger, client *redis.RedisClient, limiter *ratelimit.Limiter) (*Handler, error) {
+	params, err := password.ParamsFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.TOTP_ENCRYPTION_KEY) < minTOTPKeyLength {
+		return nil, fmt.Errorf("TOTP_ENCRYPTION_KEY must be set to a secret of at least %d characters", minTOTPKeyLength)
+	}
+
 	return &Handler{
-		UserRepo: user,
-		Logger:   logger,
+		UserRepo:    user,
+		Logger:      logger,
 		RedisClient: client,
-	}
+		Verifier:    auth.NewVerifier(user, client),
+		Limiter:     limiter,
+		Hasher:      password.NewArgon2idHasher(params),
+		Policy:      password.PolicyFromConfig(cfg),
+		TOTPKey:     cfg.TOTP_ENCRYPTION_KEY,
+	}, nil
 }
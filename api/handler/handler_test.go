@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"auth-service/config"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHandlerTOTPKey(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.Config{ARGON2_MEMORY: 64 * 1024, ARGON2_ITERATIONS: 3, ARGON2_PARALLELISM: 2}
+
+	for _, key := range []string{"", "my_totp_key"} {
+		cfg.TOTP_ENCRYPTION_KEY = key
+		_, err := NewHandler(cfg, nil, logger, nil, nil)
+		assert.Error(t, err)
+	}
+
+	cfg.TOTP_ENCRYPTION_KEY = "q3Vx0c5yC1n8bS2kL7mE9tR4wZ6pA0dF"
+	h, err := NewHandler(cfg, nil, logger, nil, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, cfg.TOTP_ENCRYPTION_KEY, h.TOTPKey)
+	}
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"auth-service/api/handler/token"
+	"auth-service/models"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary JSON Web Key Set
+// @Description Public keys used to verify access tokens issued by the auth service
+// @Tags Keys
+// @Produce json
+// @Success 200 {object} token.JWKS
+// @Failure 500 {object} models.Errors
+// @Router /.well-known/jwks.json [get]
+func (h *Handler) JWKSHandler(ctx *gin.Context) {
+	jwks, err := token.PublicJWKS()
+	if err != nil {
+		h.Logger.Error("Error loading signing keys", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "signing keys are not available",
+		})
+		return
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, jwks)
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"auth-service/config"
+	"auth-service/models"
+	"auth-service/pkg"
+	"auth-service/pkg/mailer"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errInvalidCredentials is the only answer to a wrong email or password.
+var errInvalidCredentials = models.Errors{Message: "invalid email or password"}
+
+// Failed logins are counted per account (the email as typed, so unknown
+// emails are treated the same as real ones) and per IP address.
+func loginFailKey(kind, value string) string  { return "login_fail:" + kind + ":" + value }
+func loginBlockKey(kind, value string) string { return "login_block:" + kind + ":" + value }
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// loginDelay is how long an account waits before the next attempt after
+// failures failed logins: nothing for the first LOGIN_FREE_ATTEMPTS, then 1s,
+// 2s, 4s, ... up to LOGIN_MAX_DELAY.
+func loginDelay(cfg config.Config, failures int64) time.Duration {
+	over := failures - int64(cfg.LOGIN_FREE_ATTEMPTS)
+	if over <= 0 {
+		return 0
+	}
+
+	delay := time.Duration(math.Pow(2, float64(over-1))) * time.Second
+	if delay > cfg.LOGIN_MAX_DELAY || delay <= 0 {
+		return cfg.LOGIN_MAX_DELAY
+	}
+	return delay
+}
+
+// loginBlockedFor returns how long the account and the IP address of the
+// request have to wait before the next login attempt.
+func (h *Handler) loginBlockedFor(ctx *gin.Context, email string) (time.Duration, error) {
+	accountWait, err := h.RedisClient.BlockedFor(loginBlockKey("account", email))
+	if err != nil {
+		return 0, err
+	}
+
+	ipWait, err := h.RedisClient.BlockedFor(loginBlockKey("ip", ctx.ClientIP()))
+	if err != nil {
+		return 0, err
+	}
+
+	if ipWait > accountWait {
+		return ipWait, nil
+	}
+	return accountWait, nil
+}
+
+// abortLoginBlocked answers a login attempt made while blocked.
+func abortLoginBlocked(ctx *gin.Context, wait time.Duration) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	ctx.JSON(http.StatusTooManyRequests, models.Errors{
+		Message: "too many failed login attempts, try again later",
+	})
+}
+
+// loginFailed counts a failed login and blocks the account or the IP address
+// once they have failed too often. user is nil if the email is unknown.
+func (h *Handler) loginFailed(ctx *gin.Context, email string, user *models.LoginResponse) error {
+	cfg := config.Load()
+	ip := ctx.ClientIP()
+
+	accountFailures, err := h.RedisClient.CountAttempt(loginFailKey("account", email), cfg.LOGIN_FAILURE_WINDOW)
+	if err != nil {
+		return err
+	}
+
+	ipFailures, err := h.RedisClient.CountAttempt(loginFailKey("ip", ip), cfg.LOGIN_FAILURE_WINDOW)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case accountFailures >= int64(cfg.LOGIN_MAX_ACCOUNT_FAILURES):
+		if err := h.RedisClient.Block(loginBlockKey("account", email), cfg.LOGIN_LOCKOUT); err != nil {
+			return err
+		}
+		// Hodisa va email faqat bloklangan paytda bir marta
+		if accountFailures == int64(cfg.LOGIN_MAX_ACCOUNT_FAILURES) {
+			h.accountLocked(ctx, email, user, cfg.LOGIN_LOCKOUT)
+		}
+	case loginDelay(cfg, accountFailures) > 0:
+		if err := h.RedisClient.Block(loginBlockKey("account", email), loginDelay(cfg, accountFailures)); err != nil {
+			return err
+		}
+	}
+
+	if ipFailures >= int64(cfg.LOGIN_MAX_IP_FAILURES) {
+		if err := h.RedisClient.Block(loginBlockKey("ip", ip), cfg.LOGIN_LOCKOUT); err != nil {
+			return err
+		}
+		if ipFailures == int64(cfg.LOGIN_MAX_IP_FAILURES) {
+			h.recordSecurityEvent(ctx, models.SecurityEvent{Event: models.EventIPLocked})
+		}
+	}
+
+	return nil
+}
+
+// loginSucceeded forgets the failed attempts of the account. The IP counter
+// is kept, one good password must not reset a credential stuffing run.
+func (h *Handler) loginSucceeded(email string) error {
+	return h.RedisClient.ClearAttempts(loginFailKey("account", email))
+}
+
+// accountLocked records the lockout and emails the owner a link that lifts
+// it. Nothing is sent for unknown emails.
+func (h *Handler) accountLocked(ctx *gin.Context, email string, user *models.LoginResponse, lockout time.Duration) {
+	event := models.SecurityEvent{Event: models.EventAccountLocked, Email: email}
+	if user != nil {
+		event.UserID = user.ID
+	}
+	h.recordSecurityEvent(ctx, event)
+
+	if user == nil {
+		return
+	}
+
+	unlockToken, err := pkg.GenerateRandomString(32)
+	if err == nil {
+		err = h.RedisClient.SaveUnlockToken(pkg.HashToken(unlockToken), email, lockout)
+	}
+	if err == nil {
+		err = h.queueEmail(ctx, user.Email, mailer.AccountLocked, mailer.Data{
+			Username: user.Username,
+			IP:       ctx.ClientIP(),
+			Time:     time.Now().Add(lockout).UTC().Format("2006-01-02 15:04:05") + " UTC",
+			Link:     publicLink("/api/v1/auth/unlock", url.Values{"token": {unlockToken}}),
+			Expires:  lockout,
+		})
+	}
+	if err != nil {
+		h.Logger.Error("Error queueing unlock email", slog.String("error", err.Error()))
+	}
+}
+
+func (h *Handler) recordSecurityEvent(ctx *gin.Context, event models.SecurityEvent) {
+	event.IPAddress = ctx.ClientIP()
+	event.UserAgent = ctx.Request.UserAgent()
+
+	if err := h.UserRepo.RecordSecurityEvent(event); err != nil {
+		h.Logger.Error("Error recording security event", slog.String("event", event.Event),
+			slog.String("error", err.Error()))
+	}
+}
+
+// @Summary Unlock account
+// @Description Lift a login lockout with the token from the unlock email. Each link works once
+// @Tags Auth
+// @Produce json
+// @Param token query string true "Unlock token"
+// @Success 200 {object} models.Success
+// @Failure 400 {object} models.Errors
+// @Failure 500 {object} models.Errors
+// @Router /api/v1/auth/unlock [get]
+func (h *Handler) UnlockAccountHandler(ctx *gin.Context) {
+	email, found, err := h.RedisClient.TakeUnlockToken(pkg.HashToken(ctx.Query("token")))
+	if err != nil {
+		h.Logger.Error("Error taking unlock token", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error unlock account",
+		})
+		return
+	}
+	if !found {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: "unlock link is invalid or expired",
+		})
+		return
+	}
+
+	err = h.RedisClient.ClearAttempts(loginFailKey("account", email), loginBlockKey("account", email))
+	if err != nil {
+		h.Logger.Error("Error clearing login attempts", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error unlock account",
+		})
+		return
+	}
+
+	event := models.SecurityEvent{Event: models.EventAccountUnlocked, Email: email}
+	if user, err := h.UserRepo.GetUserByEmail(email); err == nil {
+		event.UserID = user.ID
+	}
+	h.recordSecurityEvent(ctx, event)
+
+	ctx.JSON(http.StatusOK, models.Success{
+		Message: "account unlocked, you can sign in again",
+	})
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"auth-service/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginDelay(t *testing.T) {
+	cfg := config.Config{LOGIN_FREE_ATTEMPTS: 3, LOGIN_MAX_DELAY: 30 * time.Second}
+
+	assert.Equal(t, time.Duration(0), loginDelay(cfg, 1))
+	assert.Equal(t, time.Duration(0), loginDelay(cfg, 3))
+	assert.Equal(t, time.Second, loginDelay(cfg, 4))
+	assert.Equal(t, 2*time.Second, loginDelay(cfg, 5))
+	assert.Equal(t, 16*time.Second, loginDelay(cfg, 8))
+	assert.Equal(t, 30*time.Second, loginDelay(cfg, 9))
+	assert.Equal(t, 30*time.Second, loginDelay(cfg, 200))
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	assert.Equal(t, "diyorbek@example.com", normalizeEmail("  Diyorbek@Example.COM "))
+}
@@ -0,0 +1,422 @@
+package handler
+
+import (
+	"auth-service/api/handler/token"
+	"auth-service/api/middleware"
+	"auth-service/auth"
+	"auth-service/config"
+	"auth-service/models"
+	"auth-service/pkg"
+	"auth-service/pkg/totp"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// recoveryCodeCount is how many recovery codes a user gets.
+	recoveryCodeCount = 10
+	// maxMFAAttempts is how many codes can be tried with one mfa token.
+	maxMFAAttempts = 5
+)
+
+// @Summary Start two-factor enrollment
+// @Description Generates a new TOTP secret for the authenticated user. Two-factor authentication stays off until the secret is confirmed with a code from the authenticator app
+// @Tags 2FA
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} models.TOTPEnrollment
+// @Failure 401 {object} models.Errors
+// @Failure 409 {object} models.Errors
+// @Failure 500 {object} models.Errors
+// @Router /api/v1/auth/2fa/enroll [post]
+func (h *Handler) EnrollTOTPHandler(ctx *gin.Context) {
+	principal := ctx.MustGet(middleware.PrincipalKey).(*auth.Principal)
+	cfg := config.Load()
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		h.Logger.Error("Error generating totp secret", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error enroll two-factor authentication",
+		})
+		return
+	}
+
+	encrypted, err := pkg.Encrypt(h.TOTPKey, secret)
+	if err != nil {
+		h.Logger.Error("Error encrypting totp secret", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error enroll two-factor authentication",
+		})
+		return
+	}
+
+	err = h.UserRepo.SetPendingTOTPSecret(principal.UserID, encrypted)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusConflict, models.Errors{
+			Message: "two-factor authentication is already enabled",
+		})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error saving totp secret", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error enroll two-factor authentication",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.TOTPEnrollment{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(cfg.TOTP_ISSUER, principal.Email, secret),
+	})
+}
+
+// @Summary Confirm two-factor enrollment
+// @Description Turns two-factor authentication on once a code from the authenticator app matches the enrolled secret. The recovery codes are shown only in this response
+// @Tags 2FA
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Code body models.TOTPCode true "Code from the authenticator app"
+// @Success 200 {object} models.RecoveryCodes
+// @Failure 400 {object} models.Errors
+// @Failure 401 {object} models.Errors
+// @Failure 409 {object} models.Errors
+// @Failure 500 {object} models.Errors
+// @Router /api/v1/auth/2fa/confirm [post]
+func (h *Handler) ConfirmTOTPHandler(ctx *gin.Context) {
+	principal := ctx.MustGet(middleware.PrincipalKey).(*auth.Principal)
+
+	var req models.TOTPCode
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	secret, enabled, err := h.UserRepo.GetTOTPSecret(principal.UserID)
+	if err != nil {
+		h.Logger.Error("Error getting totp secret", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error confirm two-factor authentication",
+		})
+		return
+	}
+	if enabled {
+		ctx.JSON(http.StatusConflict, models.Errors{
+			Message: "two-factor authentication is already enabled",
+		})
+		return
+	}
+	if secret == "" {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: "two-factor enrollment was not started",
+		})
+		return
+	}
+
+	ok, err := h.checkTOTP(principal.UserID, secret, req.Code)
+	if err != nil {
+		h.Logger.Error("Error checking totp code", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error confirm two-factor authentication",
+		})
+		return
+	}
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: "invalid code",
+		})
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		h.Logger.Error("Error generating recovery codes", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error confirm two-factor authentication",
+		})
+		return
+	}
+
+	if err := h.UserRepo.EnableTOTP(principal.UserID, hashes); err != nil {
+		h.Logger.Error("Error enabling totp", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error confirm two-factor authentication",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.RecoveryCodes{
+		Codes: codes,
+	})
+}
+
+// @Summary Disable two-factor authentication
+// @Description Turns two-factor authentication off after checking a current code, and deletes the recovery codes
+// @Tags 2FA
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Code body models.TOTPCode true "Code from the authenticator app"
+// @Success 200 {object} models.Success
+// @Failure 400 {object} models.Errors
+// @Failure 401 {object} models.Errors
+// @Failure 500 {object} models.Errors
+// @Router /api/v1/auth/2fa/disable [post]
+func (h *Handler) DisableTOTPHandler(ctx *gin.Context) {
+	principal := ctx.MustGet(middleware.PrincipalKey).(*auth.Principal)
+
+	var req models.TOTPCode
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	secret, enabled, err := h.UserRepo.GetTOTPSecret(principal.UserID)
+	if err != nil {
+		h.Logger.Error("Error getting totp secret", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error disable two-factor authentication",
+		})
+		return
+	}
+	if !enabled {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: "two-factor authentication is not enabled",
+		})
+		return
+	}
+
+	ok, err := h.checkTOTP(principal.UserID, secret, req.Code)
+	if err != nil {
+		h.Logger.Error("Error checking totp code", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error disable two-factor authentication",
+		})
+		return
+	}
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: "invalid code",
+		})
+		return
+	}
+
+	if err := h.UserRepo.DisableTOTP(principal.UserID); err != nil {
+		h.Logger.Error("Error disabling totp", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error disable two-factor authentication",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Success{
+		Message: "two-factor authentication disabled",
+	})
+}
+
+// @Summary Finish login with the second factor
+// @Description Completes a login that returned mfa_required. Takes the mfa_token and either a TOTP code or one of the recovery codes, each recovery code works once. Wrong codes count as failed logins of the account
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param Login body models.MFALoginRequest true "Second factor"
+// @Success 200 {object} models.Token
+// @Failure 400 {object} models.Errors
+// @Failure 401 {object} models.Errors
+// @Failure 429 {object} models.Errors
+// @Failure 500 {object} models.Errors
+// @Router /api/v1/auth/login/mfa [post]
+func (h *Handler) LoginMFAHandler(ctx *gin.Context) {
+	var req models.MFALoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	claims, err := token.ExtractClaimsMFA(req.MFAToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, models.Errors{
+			Message: "mfa token invalid",
+		})
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(claims.UserId)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusUnauthorized, models.Errors{
+			Message: "mfa token invalid",
+		})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error getting user by id", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error login",
+		})
+		return
+	}
+
+	// Noto'g'ri kodlar parol kabi akkauntning xato urinishlariga qo'shiladi,
+	// shuning uchun qayta login qilib yangi mfa token olish yordam bermaydi
+	email := normalizeEmail(user.Email)
+	wait, err := h.loginBlockedFor(ctx, email)
+	if err != nil {
+		h.Logger.Error("Error checking login attempts", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error login",
+		})
+		return
+	}
+	if wait > 0 {
+		abortLoginBlocked(ctx, wait)
+		return
+	}
+
+	// Bitta mfa token bilan cheklangan miqdorda kod sinab ko'rish mumkin
+	attempts, err := h.RedisClient.CountAttempt("mfa_attempts:"+claims.Id, token.MFATokenTTL)
+	if err != nil {
+		h.Logger.Error("Error counting mfa attempts", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error login",
+		})
+		return
+	}
+	if attempts > maxMFAAttempts {
+		ctx.JSON(http.StatusUnauthorized, models.Errors{
+			Message: "too many attempts, login again",
+		})
+		return
+	}
+
+	secret, enabled, err := h.UserRepo.GetTOTPSecret(claims.UserId)
+	if err == sql.ErrNoRows || (err == nil && !enabled) {
+		ctx.JSON(http.StatusUnauthorized, models.Errors{
+			Message: "mfa token invalid",
+		})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error getting totp secret", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error login",
+		})
+		return
+	}
+
+	var ok bool
+	switch {
+	case req.Code != "":
+		ok, err = h.checkTOTP(claims.UserId, secret, req.Code)
+	case req.RecoveryCode != "":
+		ok, err = h.UserRepo.UseRecoveryCode(claims.UserId, pkg.HashToken(normalizeRecoveryCode(req.RecoveryCode)))
+	default:
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: "code or recovery_code is required",
+		})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error checking second factor", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error login",
+		})
+		return
+	}
+	if !ok {
+		if err := h.loginFailed(ctx, email, user); err != nil {
+			h.Logger.Error("Error counting failed login", slog.String("error", err.Error()))
+		}
+		ctx.JSON(http.StatusUnauthorized, models.Errors{
+			Message: "invalid code",
+		})
+		return
+	}
+
+	// mfa token faqat bir marta ishlatiladi
+	first, err := h.RedisClient.MarkOnce("mfa_used:"+claims.Id, token.MFATokenTTL)
+	if err != nil {
+		h.Logger.Error("Error marking mfa token used", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error login",
+		})
+		return
+	}
+	if !first {
+		ctx.JSON(http.StatusUnauthorized, models.Errors{
+			Message: "mfa token invalid",
+		})
+		return
+	}
+
+	if err := h.loginSucceeded(email); err != nil {
+		h.Logger.Error("Error clearing login attempts", slog.String("error", err.Error()))
+	}
+
+	newToken, err := h.startLogin(ctx, user)
+	if err != nil {
+		h.Logger.Error("error in start session", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
+	h.Logger.Info("user login successfully")
+
+	ctx.JSON(http.StatusOK, newToken)
+}
+
+// checkTOTP checks a code against the encrypted secret of the user. A code
+// is accepted only once, even though it stays valid for its whole time step.
+func (h *Handler) checkTOTP(userID, encryptedSecret, code string) (bool, error) {
+	secret, err := pkg.Decrypt(h.TOTPKey, encryptedSecret)
+	if err != nil {
+		return false, err
+	}
+
+	step, ok, err := totp.Validate(secret, code, time.Now())
+	if err != nil || !ok {
+		return false, err
+	}
+
+	window := time.Duration(2*totp.Skew+1) * totp.Period * time.Second
+	return h.RedisClient.MarkOnce(fmt.Sprintf("totp_used:%s:%d", userID, step), window)
+}
+
+// generateRecoveryCodes returns new recovery codes in the form shown to the
+// user ("xxxxx-xxxxx") and their hashes to store.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := pkg.GenerateRandomString(5)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, pkg.HashToken(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode accepts codes typed with or without the dash and in
+// any case.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
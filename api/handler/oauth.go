@@ -0,0 +1,568 @@
+package handler
+
+import (
+	"auth-service/api/handler/token"
+	"auth-service/api/middleware"
+	"auth-service/auth"
+	"auth-service/models"
+	"auth-service/pkg"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// @Summary Token introspection
+// @Description RFC 7662 token introspection. Checks signature, expiry, the logout blacklist and the session of an access token. The caller authenticates as a confidential client with the tokens:introspect scope, with HTTP Basic or client_id and client_secret
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Access token"
+// @Param token_type_hint formData string false "Token type hint"
+// @Param client_id formData string false "Client id"
+// @Param client_secret formData string false "Client secret"
+// @Success 200 {object} models.IntrospectionResponse
+// @Failure 400 {object} models.OAuthError
+// @Failure 401 {object} models.OAuthError
+// @Failure 500 {object} models.OAuthError
+// @Router /oauth/introspect [post]
+func (h *Handler) IntrospectHandler(ctx *gin.Context) {
+	client, ok := h.authenticateClient(ctx)
+	if !ok {
+		return
+	}
+	// Public clients have no secret to prove who they are, and other clients
+	// need the tokens:introspect scope
+	if !client.Confidential || !containsAll(client.Scopes, []string{auth.ScopeTokensIntrospect}) {
+		ctx.JSON(http.StatusUnauthorized, models.OAuthError{
+			Error:            "invalid_client",
+			ErrorDescription: "client is not allowed to introspect tokens",
+		})
+		return
+	}
+
+	tokenString := ctx.PostForm("token")
+	if tokenString == "" {
+		ctx.JSON(http.StatusBadRequest, models.OAuthError{
+			Error:            "invalid_request",
+			ErrorDescription: "token is required",
+		})
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+
+	claims, err := h.Verifier.Verify(tokenString)
+	if errors.Is(err, auth.ErrInvalidToken) {
+		ctx.JSON(http.StatusOK, models.IntrospectionResponse{Active: false})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error in token introspection", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.OAuthError{
+			Error: "server_error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.IntrospectionResponse{
+		Active:    true,
+		TokenType: "Bearer",
+		Sub:       claims.UserId,
+		Username:  claims.Username,
+		Email:     claims.Email,
+		SessionID: claims.SessionId,
+		Role:      claims.Role,
+		ClientID:  claims.ClientId,
+		Scope:     claims.Scope,
+		Iat:       claims.IssuedAt,
+		Exp:       claims.ExpiresAt,
+	})
+}
+
+// authCodeTTL is how long an authorization code can be redeemed
+// (RFC 6749, 4.1.2 recommends at most ten minutes).
+const authCodeTTL = 5 * time.Minute
+
+// @Summary Authorization endpoint
+// @Description OAuth 2.0 authorization code request with PKCE (S256) made by the frontend on behalf of the signed in user. If the user already consented to the requested scopes the response carries redirect_to with the code, otherwise consent_required is set and the frontend asks the user and calls POST /oauth/authorize
+// @Tags OAuth
+// @Security ApiKeyAuth
+// @Produce json
+// @Param response_type query string true "Must be code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "Registered redirect URI"
+// @Param scope query string false "Space separated scopes"
+// @Param state query string false "Opaque client state"
+// @Param code_challenge query string true "PKCE code challenge"
+// @Param code_challenge_method query string true "Must be S256"
+// @Param nonce query string false "OpenID Connect nonce, copied into the ID token"
+// @Success 200 {object} models.AuthorizeResponse
+// @Failure 400 {object} models.OAuthError
+// @Failure 401 {object} models.Errors
+// @Failure 500 {object} models.OAuthError
+// @Router /oauth/authorize [get]
+func (h *Handler) AuthorizeHandler(ctx *gin.Context) {
+	var req models.AuthorizeRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.OAuthError{
+			Error:            "invalid_request",
+			ErrorDescription: err.Error(),
+		})
+		return
+	}
+
+	client, scopes, ok := h.checkAuthorizeRequest(ctx, &req)
+	if !ok {
+		return
+	}
+
+	principal := ctx.MustGet(middleware.PrincipalKey).(*auth.Principal)
+	granted, err := h.UserRepo.GetConsentScopes(principal.UserID, client.ClientID)
+	if err != nil && err != sql.ErrNoRows {
+		h.Logger.Error("Error getting consent", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.OAuthError{Error: "server_error"})
+		return
+	}
+
+	if !containsAll(granted, scopes) {
+		ctx.JSON(http.StatusOK, models.AuthorizeResponse{
+			ConsentRequired: true,
+			ClientID:        client.ClientID,
+			ClientName:      client.Name,
+			Scope:           strings.Join(scopes, " "),
+		})
+		return
+	}
+
+	h.issueAuthCode(ctx, principal, &req, scopes)
+}
+
+// @Summary Authorization consent
+// @Description Records the user's decision on the consent screen. On approval the consent is stored and redirect_to carries the authorization code, otherwise it carries error=access_denied
+// @Tags OAuth
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Authorize body models.AuthorizeRequest true "Authorization request and decision"
+// @Success 200 {object} models.AuthorizeResponse
+// @Failure 400 {object} models.OAuthError
+// @Failure 401 {object} models.Errors
+// @Failure 500 {object} models.OAuthError
+// @Router /oauth/authorize [post]
+func (h *Handler) AuthorizeConsentHandler(ctx *gin.Context) {
+	var req models.AuthorizeRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.OAuthError{
+			Error:            "invalid_request",
+			ErrorDescription: err.Error(),
+		})
+		return
+	}
+
+	client, scopes, ok := h.checkAuthorizeRequest(ctx, &req)
+	if !ok {
+		return
+	}
+
+	if !req.Approve {
+		ctx.JSON(http.StatusOK, models.AuthorizeResponse{
+			RedirectTo: redirectWithError(req.RedirectURI, req.State, "access_denied"),
+		})
+		return
+	}
+
+	principal := ctx.MustGet(middleware.PrincipalKey).(*auth.Principal)
+	if err := h.UserRepo.SaveConsent(principal.UserID, client.ClientID, scopes); err != nil {
+		h.Logger.Error("Error saving consent", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.OAuthError{Error: "server_error"})
+		return
+	}
+
+	h.issueAuthCode(ctx, principal, &req, scopes)
+}
+
+// checkAuthorizeRequest validates an authorization request and writes the
+// error response if it is invalid. Problems with the client or redirect URI
+// are reported to the caller; everything else is sent to the client through
+// its redirect URI (RFC 6749, 4.1.2.1).
+func (h *Handler) checkAuthorizeRequest(ctx *gin.Context, req *models.AuthorizeRequest) (*models.OAuthClient, []string, bool) {
+	client, err := h.UserRepo.GetOAuthClient(req.ClientID)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusBadRequest, models.OAuthError{
+			Error:            "invalid_client",
+			ErrorDescription: "unknown client_id",
+		})
+		return nil, nil, false
+	}
+	if err != nil {
+		h.Logger.Error("Error getting oauth client", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.OAuthError{Error: "server_error"})
+		return nil, nil, false
+	}
+
+	if req.RedirectURI == "" || !containsAll(client.RedirectURIs, []string{req.RedirectURI}) {
+		ctx.JSON(http.StatusBadRequest, models.OAuthError{
+			Error:            "invalid_request",
+			ErrorDescription: "redirect_uri is not registered for this client",
+		})
+		return nil, nil, false
+	}
+
+	fail := func(code string) (*models.OAuthClient, []string, bool) {
+		ctx.JSON(http.StatusOK, models.AuthorizeResponse{
+			RedirectTo: redirectWithError(req.RedirectURI, req.State, code),
+		})
+		return nil, nil, false
+	}
+
+	if req.ResponseType != "code" {
+		return fail("unsupported_response_type")
+	}
+
+	// PKCE har bir client uchun majburiy, faqat S256 qabul qilinadi
+	if req.CodeChallengeMethod != "S256" || len(req.CodeChallenge) < 43 || len(req.CodeChallenge) > 128 {
+		return fail("invalid_request")
+	}
+
+	scopes := strings.Fields(req.Scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	if !containsAll(client.Scopes, scopes) {
+		return fail("invalid_scope")
+	}
+
+	return client, scopes, true
+}
+
+// issueAuthCode stores a new authorization code and answers with the redirect
+// URI that hands it to the client.
+func (h *Handler) issueAuthCode(ctx *gin.Context, principal *auth.Principal, req *models.AuthorizeRequest, scopes []string) {
+	code, err := pkg.GenerateRandomString(32)
+	if err != nil {
+		h.Logger.Error("Error generating authorization code", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.OAuthError{Error: "server_error"})
+		return
+	}
+
+	data, err := json.Marshal(models.AuthorizationCode{
+		ClientID:      req.ClientID,
+		UserID:        principal.UserID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         strings.Join(scopes, " "),
+		CodeChallenge: req.CodeChallenge,
+		Nonce:         req.Nonce,
+	})
+	if err != nil {
+		h.Logger.Error("Error encoding authorization code", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.OAuthError{Error: "server_error"})
+		return
+	}
+
+	if err := h.RedisClient.SaveAuthCode(pkg.HashToken(code), data, authCodeTTL); err != nil {
+		h.Logger.Error("Error saving authorization code", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.OAuthError{Error: "server_error"})
+		return
+	}
+
+	params := url.Values{}
+	params.Set("code", code)
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+
+	ctx.JSON(http.StatusOK, models.AuthorizeResponse{
+		RedirectTo: withQuery(req.RedirectURI, params),
+	})
+}
+
+// @Summary Token endpoint
+// @Description OAuth 2.0 token endpoint. Supports the authorization_code grant (with PKCE code_verifier), the refresh_token grant and the client_credentials grant for service clients. Confidential clients authenticate with HTTP Basic or client_secret in the form
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code, refresh_token or client_credentials"
+// @Param client_id formData string false "Client ID"
+// @Param client_secret formData string false "Client secret of confidential clients"
+// @Param code formData string false "Authorization code"
+// @Param redirect_uri formData string false "Redirect URI used in the authorization request"
+// @Param code_verifier formData string false "PKCE code verifier"
+// @Param refresh_token formData string false "Refresh token"
+// @Param scope formData string false "Requested scopes of client_credentials"
+// @Success 200 {object} models.OAuthToken
+// @Failure 400 {object} models.OAuthError
+// @Failure 401 {object} models.OAuthError
+// @Failure 500 {object} models.OAuthError
+// @Router /oauth/token [post]
+func (h *Handler) TokenHandler(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Pragma", "no-cache")
+
+	client, ok := h.authenticateClient(ctx)
+	if !ok {
+		return
+	}
+
+	grantType := ctx.PostForm("grant_type")
+	switch grantType {
+	case "authorization_code", "refresh_token", "client_credentials":
+	default:
+		ctx.JSON(http.StatusBadRequest, models.OAuthError{
+			Error: "unsupported_grant_type",
+		})
+		return
+	}
+
+	if !containsAll(client.GrantTypes, []string{grantType}) {
+		ctx.JSON(http.StatusBadRequest, models.OAuthError{
+			Error: "unauthorized_client",
+		})
+		return
+	}
+
+	switch grantType {
+	case "authorization_code":
+		h.authorizationCodeGrant(ctx, client)
+	case "refresh_token":
+		h.refreshTokenGrant(ctx, client)
+	case "client_credentials":
+		h.clientCredentialsGrant(ctx, client)
+	}
+}
+
+// authenticateClient identifies the client of a token request. Confidential
+// clients must present their secret, public clients only their client_id.
+func (h *Handler) authenticateClient(ctx *gin.Context) (*models.OAuthClient, bool) {
+	clientID, secret, basic := ctx.Request.BasicAuth()
+	if !basic {
+		clientID = ctx.PostForm("client_id")
+		secret = ctx.PostForm("client_secret")
+	}
+
+	invalid := func() (*models.OAuthClient, bool) {
+		ctx.JSON(http.StatusUnauthorized, models.OAuthError{
+			Error: "invalid_client",
+		})
+		return nil, false
+	}
+
+	if clientID == "" {
+		return invalid()
+	}
+
+	client, err := h.UserRepo.GetOAuthClient(clientID)
+	if err == sql.ErrNoRows {
+		return invalid()
+	}
+	if err != nil {
+		h.Logger.Error("Error getting oauth client", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.OAuthError{Error: "server_error"})
+		return nil, false
+	}
+
+	if client.Confidential && bcrypt.CompareHashAndPassword([]byte(client.SecretHash), []byte(secret)) != nil {
+		return invalid()
+	}
+
+	return client, true
+}
+
+func (h *Handler) authorizationCodeGrant(ctx *gin.Context, client *models.OAuthClient) {
+	invalidGrant := func(description string) {
+		ctx.JSON(http.StatusBadRequest, models.OAuthError{
+			Error:            "invalid_grant",
+			ErrorDescription: description,
+		})
+	}
+
+	data, found, err := h.RedisClient.TakeAuthCode(pkg.HashToken(ctx.PostForm("code")))
+	if err != nil {
+		h.Logger.Error("Error getting authorization code", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.OAuthError{Error: "server_error"})
+		return
+	}
+	if !found {
+		invalidGrant("authorization code is invalid or expired")
+		return
+	}
+
+	var code models.AuthorizationCode
+	if err := json.Unmarshal(data, &code); err != nil {
+		h.Logger.Error("Error decoding authorization code", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.OAuthError{Error: "server_error"})
+		return
+	}
+
+	if code.ClientID != client.ClientID || code.RedirectURI != ctx.PostForm("redirect_uri") {
+		invalidGrant("authorization code was issued to another client or redirect_uri")
+		return
+	}
+
+	if !verifyCodeChallenge(ctx.PostForm("code_verifier"), code.CodeChallenge) {
+		invalidGrant("code_verifier does not match the code challenge")
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(code.UserID)
+	if err == sql.ErrNoRows {
+		invalidGrant("user no longer exists")
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error getting user by id", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.OAuthError{Error: "server_error"})
+		return
+	}
+
+	newToken, session, err := h.startSession(ctx, user, client.ClientID, code.Scope)
+	if err != nil {
+		h.Logger.Error("error in start session", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.OAuthError{Error: "server_error"})
+		return
+	}
+
+	h.respondWithToken(ctx, newToken, session, code.Nonce)
+}
+
+func (h *Handler) refreshTokenGrant(ctx *gin.Context, client *models.OAuthClient) {
+	newToken, session, err := h.rotateSession(ctx, ctx.PostForm("refresh_token"), client.ClientID)
+	if err == errInvalidRefreshToken {
+		ctx.JSON(http.StatusBadRequest, models.OAuthError{
+			Error: "invalid_grant",
+		})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("error in rotate refresh token", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.OAuthError{Error: "server_error"})
+		return
+	}
+
+	h.respondWithToken(ctx, newToken, session, "")
+}
+
+// clientCredentialsGrant issues a short-lived token to a service client
+// acting for itself (RFC 6749, 4.4). No refresh token is issued.
+func (h *Handler) clientCredentialsGrant(ctx *gin.Context, client *models.OAuthClient) {
+	// Faqat maxfiy (confidential) clientlar o'zini tasdiqlay oladi
+	if !client.Confidential {
+		ctx.JSON(http.StatusBadRequest, models.OAuthError{
+			Error: "unauthorized_client",
+		})
+		return
+	}
+
+	scopes := strings.Fields(ctx.PostForm("scope"))
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	if !containsAll(client.Scopes, scopes) {
+		ctx.JSON(http.StatusBadRequest, models.OAuthError{
+			Error: "invalid_scope",
+		})
+		return
+	}
+
+	scope := strings.Join(scopes, " ")
+	accessToken, err := token.GenerateClientJWT(client.ClientID, scope)
+	if err != nil {
+		h.Logger.Error("Error generating client token", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.OAuthError{Error: "server_error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.OAuthToken{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(token.ClientTokenTTL.Seconds()),
+		Scope:       scope,
+	})
+}
+
+// respondWithToken writes the token endpoint response. Sessions granted the
+// openid scope also get an ID token.
+func (h *Handler) respondWithToken(ctx *gin.Context, newToken *models.Token, session *models.Session, nonce string) {
+	resp := models.OAuthToken{
+		AccessToken:  newToken.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(token.AccessTokenTTL.Seconds()),
+		RefreshToken: newToken.RefreshToken,
+		Scope:        session.Scope,
+	}
+
+	if auth.HasScope(session.Scope, "openid") {
+		idToken, err := h.generateIDToken(session, nonce)
+		if err != nil {
+			h.Logger.Error("Error generating id token", slog.String("error", err.Error()))
+			ctx.JSON(http.StatusInternalServerError, models.OAuthError{Error: "server_error"})
+			return
+		}
+		resp.IDToken = idToken
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// verifyCodeChallenge checks a PKCE code_verifier against its S256 challenge
+// (RFC 7636, 4.6).
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+func redirectWithError(redirectURI, state, code string) string {
+	params := url.Values{}
+	params.Set("error", code)
+	if state != "" {
+		params.Set("state", state)
+	}
+	return withQuery(redirectURI, params)
+}
+
+// withQuery adds params to the query of a registered redirect URI, keeping
+// the query it already has.
+func withQuery(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// containsAll reports whether every item of want is in have.
+func containsAll(have, want []string) bool {
+	set := make(map[string]bool, len(have))
+	for _, item := range have {
+		set[item] = true
+	}
+
+	for _, item := range want {
+		if !set[item] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	// RFC 7636, Appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	assert.True(t, verifyCodeChallenge(verifier, challenge))
+	assert.False(t, verifyCodeChallenge(verifier+"x", challenge))
+	assert.False(t, verifyCodeChallenge("short", challenge))
+	assert.False(t, verifyCodeChallenge("", ""))
+}
+
+func TestWithQuery(t *testing.T) {
+	got := redirectWithError("https://app.example.com/cb?tab=1", "xyz", "access_denied")
+	assert.Equal(t, "https://app.example.com/cb?error=access_denied&state=xyz&tab=1", got)
+}
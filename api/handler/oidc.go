@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"auth-service/api/handler/token"
+	"auth-service/api/middleware"
+	"auth-service/auth"
+	"auth-service/config"
+	pb "auth-service/generated/user"
+	"auth-service/models"
+	"database/sql"
+	"log/slog"
+	"net/http"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary OpenID Connect discovery
+// @Description OpenID Provider metadata used by OIDC client libraries
+// @Tags OAuth
+// @Produce json
+// @Success 200 {object} models.OpenIDConfiguration
+// @Failure 500 {object} models.Errors
+// @Router /.well-known/openid-configuration [get]
+func (h *Handler) OpenIDConfigurationHandler(ctx *gin.Context) {
+	algs, err := token.SigningAlgorithms()
+	if err != nil {
+		h.Logger.Error("Error loading signing keys", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "signing keys are not available",
+		})
+		return
+	}
+
+	issuer := config.Load().ISSUER_URL
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, models.OpenIDConfiguration{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/oauth/authorize",
+		TokenEndpoint:                     issuer + "/oauth/token",
+		UserinfoEndpoint:                  issuer + "/userinfo",
+		JwksURI:                           issuer + "/.well-known/jwks.json",
+		IntrospectionEndpoint:             issuer + "/oauth/introspect",
+		ScopesSupported:                   []string{"openid", "profile", "email"},
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "refresh_token", "client_credentials"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  algs,
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "nonce", "email", "email_verified", "preferred_username", "name"},
+	})
+}
+
+// @Summary OpenID Connect userinfo
+// @Description Claims about the user of the access token. Tokens issued to OAuth clients need the openid scope, and only see the profile and email claims of the scopes they were granted
+// @Tags OAuth
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} models.UserInfo
+// @Failure 401 {object} models.Errors
+// @Failure 403 {object} models.OAuthError
+// @Failure 500 {object} models.Errors
+// @Router /userinfo [get]
+func (h *Handler) UserInfoHandler(ctx *gin.Context) {
+	principal := ctx.MustGet(middleware.PrincipalKey).(*auth.Principal)
+
+	// Birinchi tomon (first-party) tokenlarida client yo'q, ularga hamma claimlar beriladi
+	firstParty := principal.ClientID == ""
+	if !firstParty && !auth.HasScope(principal.Scope, "openid") {
+		ctx.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+		ctx.JSON(http.StatusForbidden, models.OAuthError{
+			Error: "insufficient_scope",
+		})
+		return
+	}
+
+	profile, err := h.UserRepo.GetUserProfile(principal.UserID, "")
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusUnauthorized, models.Errors{
+			Message: "user not found",
+		})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error getting user profile", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error getting user profile",
+		})
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, userInfo(profile, principal.Scope, firstParty))
+}
+
+// userInfo returns the claims of profile allowed by scope. all ignores the
+// scope.
+func userInfo(profile *pb.GetProfileResponse, scope string, all bool) models.UserInfo {
+	info := models.UserInfo{Sub: profile.Id}
+
+	if all || auth.HasScope(scope, "email") {
+		info.Email = profile.Email
+		info.EmailVerified = &profile.EmailVerified
+	}
+	if all || auth.HasScope(scope, "profile") {
+		info.PreferredUsername = profile.Username
+		info.Name = profile.FullName
+	}
+
+	return info
+}
+
+// generateIDToken issues an ID token for a session granted to an OAuth client. The
+// claims are read from the users table so they reflect the current profile.
+func (h *Handler) generateIDToken(session *models.Session, nonce string) (string, error) {
+	profile, err := h.UserRepo.GetUserProfile(session.UserID, "")
+	if err != nil {
+		return "", err
+	}
+
+	info := userInfo(profile, session.Scope, false)
+
+	return token.GenerateIDToken(token.IDClaims{
+		Nonce:             nonce,
+		Email:             info.Email,
+		EmailVerified:     info.EmailVerified,
+		PreferredUsername: info.PreferredUsername,
+		Name:              info.Name,
+		StandardClaims: jwt.StandardClaims{
+			Issuer:   config.Load().ISSUER_URL,
+			Subject:  profile.Id,
+			Audience: session.ClientID,
+		},
+	})
+}
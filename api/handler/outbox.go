@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"auth-service/models"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary List outbox emails
+// @Description List the queued emails with a status, newest first. Dead emails failed every delivery attempt and wait for a retry
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param status query string false "pending, sent or dead" default(dead)
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} models.OutboxEmails
+// @Failure 400 {object} models.Errors
+// @Failure 401 {object} models.Errors
+// @Failure 403 {object} models.Errors
+// @Failure 500 {object} models.Errors
+// @Router /api/v1/admin/outbox [get]
+func (h *Handler) ListOutboxHandler(ctx *gin.Context) {
+	status := ctx.DefaultQuery("status", models.OutboxDead)
+	if status != models.OutboxPending && status != models.OutboxSent && status != models.OutboxDead {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: "status must be pending, sent or dead",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: "limit must be between 1 and 500",
+		})
+		return
+	}
+
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: "invalid offset",
+		})
+		return
+	}
+
+	emails, err := h.UserRepo.ListOutboxEmails(status, limit, offset)
+	if err != nil {
+		h.Logger.Error("Error listing outbox emails", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error list outbox emails",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.OutboxEmails{
+		Emails: emails,
+	})
+}
+
+// @Summary Retry outbox email
+// @Description Put a dead email back in the queue with a fresh set of delivery attempts
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "Email id"
+// @Success 200 {object} models.Success
+// @Failure 401 {object} models.Errors
+// @Failure 403 {object} models.Errors
+// @Failure 404 {object} models.Errors
+// @Failure 500 {object} models.Errors
+// @Router /api/v1/admin/outbox/{id}/retry [post]
+func (h *Handler) RetryOutboxHandler(ctx *gin.Context) {
+	err := h.UserRepo.RetryOutboxEmail(ctx.Param("id"))
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, models.Errors{
+			Message: "no dead email with this id",
+		})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error retrying outbox email", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error retry outbox email",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Success{
+		Message: "email queued again",
+	})
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"auth-service/models"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// dummyPasswordHash is verified against when the email is unknown, so a
+// failed login takes as long whether or not the account exists. It is made
+// with the current parameters, which is what most stored hashes use.
+func (h *Handler) dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		hash, err := h.Hasher.Hash("travel-tales-dummy-password")
+		if err != nil {
+			h.Logger.Error("Error hashing dummy password", slog.String("error", err.Error()))
+			return
+		}
+		dummyHash = hash
+	})
+	return dummyHash
+}
+
+// upgradePasswordHash stores a new hash of the password the user just logged
+// in with if the stored one uses an older algorithm or older parameters. A
+// failure only means the upgrade is tried again on the next login.
+func (h *Handler) upgradePasswordHash(user *models.LoginResponse, password string) {
+	if !h.Hasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	newHash, err := h.Hasher.Hash(password)
+	if err == nil {
+		err = h.UserRepo.ReplacePasswordHash(user.ID, user.Password, newHash)
+	}
+	if err != nil {
+		h.Logger.Error("Error upgrading password hash", slog.String("error", err.Error()))
+		return
+	}
+
+	user.Password = newHash
+}
+
+// checkPasswordPolicy answers with 400 and the broken rules if password does
+// not satisfy the policy and reports whether it does. If the breached
+// password corpus cannot be read the other rules still apply.
+func (h *Handler) checkPasswordPolicy(ctx *gin.Context, password, username, email string) bool {
+	violations, err := h.Policy.Check(password, username, email)
+	if err != nil {
+		h.Logger.Error("Error checking breached passwords", slog.String("error", err.Error()))
+	}
+	if len(violations) == 0 {
+		return true
+	}
+
+	resp := models.PasswordPolicyError{
+		Message: "password does not meet the password policy",
+	}
+	for _, v := range violations {
+		resp.Violations = append(resp.Violations, models.PasswordViolation{Rule: v.Rule, Message: v.Message})
+	}
+
+	ctx.AbortWithStatusJSON(http.StatusBadRequest, resp)
+	return false
+}
@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"auth-service/api/handler/token"
+	"auth-service/models"
+	"auth-service/pkg"
+	"auth-service/pkg/mailer"
+	"auth-service/storage/postgres"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// refreshTokenTTL is how long a session stays alive without being refreshed.
+const refreshTokenTTL = 7 * 24 * time.Hour
+
+// errInvalidRefreshToken is returned by rotateSession for refresh tokens that
+// are malformed, expired, reused or belong to an ended session.
+var errInvalidRefreshToken = errors.New("invalid refresh token")
+
+// startSession creates a session for the device making the request and issues
+// the first access/refresh token pair for it. clientID and scope are empty
+// for first-party logins and set for sessions granted through OAuth.
+func (h *Handler) startSession(ctx *gin.Context, user *models.LoginResponse, clientID, scope string) (*models.Token, *models.Session, error) {
+	session := models.Session{
+		UserID:    user.ID,
+		UserAgent: ctx.Request.UserAgent(),
+		IPAddress: ctx.ClientIP(),
+		ClientID:  clientID,
+		Scope:     scope,
+	}
+
+	sessionID, err := h.UserRepo.CreateSession(session, time.Now().Add(refreshTokenTTL))
+	if err != nil {
+		return nil, nil, err
+	}
+	session.ID = sessionID
+
+	accessToken, err := token.GenerateAccessJWT(user, &session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken, err := token.GenerateRefreshJWT(user, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = h.UserRepo.SetSessionRefreshToken(sessionID, pkg.HashToken(refreshToken))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &models.Token{
+		AccessToken:   accessToken,
+		RefreshToken:  refreshToken,
+		EmailVerified: user.EmailVerified,
+	}, &session, nil
+}
+
+// startLogin starts a first-party session after a successful login. If the
+// user has signed in before but never from this device, they get an email
+// about the new sign-in.
+func (h *Handler) startLogin(ctx *gin.Context, user *models.LoginResponse) (*models.Token, error) {
+	known, err := h.UserRepo.IsKnownDevice(user.ID, ctx.Request.UserAgent())
+	if err != nil {
+		return nil, err
+	}
+
+	newToken, _, err := h.startSession(ctx, user, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	if !known {
+		// Xabar yuborilmasa ham login muvaffaqiyatli
+		err = h.queueEmail(ctx, user.Email, mailer.NewDeviceLogin, mailer.Data{
+			Username: user.Username,
+			Device:   ctx.Request.UserAgent(),
+			IP:       ctx.ClientIP(),
+			Time:     time.Now().UTC().Format("2006-01-02 15:04:05") + " UTC",
+			Link:     publicLink("/reset-password", nil),
+		})
+		if err != nil {
+			h.Logger.Error("Error queueing new device email", slog.String("error", err.Error()))
+		}
+	}
+
+	return newToken, nil
+}
+
+// rotateSession exchanges a refresh token for a new token pair and retires
+// the presented token. A retired token presented again revokes its session.
+// clientID must match the client the session was granted to, empty for
+// first-party sessions.
+func (h *Handler) rotateSession(ctx *gin.Context, refreshToken, clientID string) (*models.Token, *models.Session, error) {
+	claims, err := token.ExtractClaims(refreshToken)
+	if err != nil {
+		return nil, nil, errInvalidRefreshToken
+	}
+
+	session, err := h.UserRepo.GetSession(claims.SessionId)
+	if err == sql.ErrNoRows {
+		return nil, nil, errInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if session.Revoked || session.ClientID != clientID {
+		return nil, nil, errInvalidRefreshToken
+	}
+
+	oldHash := pkg.HashToken(refreshToken)
+	if session.RefreshTokenHash != oldHash {
+		h.revokeReusedSession(ctx, session)
+		return nil, nil, errInvalidRefreshToken
+	}
+
+	// Rol o'zgargan yoki user o'chirilgan bo'lishi mumkin, shuning uchun bazadan qayta o'qiladi
+	user, err := h.UserRepo.GetUserByID(session.UserID)
+	if err == sql.ErrNoRows {
+		return nil, nil, errInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newAccessToken, err := token.GenerateAccessJWT(user, session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newRefreshToken, err := token.GenerateRefreshJWT(user, session.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = h.UserRepo.RotateRefreshToken(session.ID, oldHash, pkg.HashToken(newRefreshToken), time.Now().Add(refreshTokenTTL))
+	if err == postgres.ErrRefreshTokenReused {
+		// Token boshqa so'rov tomonidan allaqachon almashtirilgan
+		h.revokeReusedSession(ctx, session)
+		return nil, nil, errInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &models.Token{
+		AccessToken:   newAccessToken,
+		RefreshToken:  newRefreshToken,
+		EmailVerified: user.EmailVerified,
+	}, session, nil
+}
+
+// revokeReusedSession handles a retired refresh token being presented again:
+// the session it belongs to is revoked and a security event is recorded.
+func (h *Handler) revokeReusedSession(ctx *gin.Context, session *models.Session) {
+	h.Logger.Warn("refresh token reuse detected",
+		slog.String("event", "refresh_token_reuse"),
+		slog.String("user_id", session.UserID),
+		slog.String("session_id", session.ID),
+		slog.String("client_id", session.ClientID),
+		slog.String("ip", ctx.ClientIP()),
+		slog.String("user_agent", ctx.Request.UserAgent()),
+	)
+
+	h.recordSecurityEvent(ctx, models.SecurityEvent{
+		UserID: session.UserID,
+		Event:  models.EventRefreshTokenReused,
+	})
+
+	if err := h.UserRepo.RevokeSession(session.ID, session.UserID); err != nil && err != sql.ErrNoRows {
+		h.Logger.Error("error in revoke session", slog.String("error", err.Error()))
+	}
+}
+
+// @Summary List sessions
+// @Description List the active sessions (devices) of the authenticated user
+// @Tags Sessions
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} models.Sessions
+// @Failure 401 {object} models.Errors
+// @Failure 500 {object} models.Errors
+// @Router /api/v1/sessions [get]
+func (h *Handler) ListSessionsHandler(ctx *gin.Context) {
+	sessions, err := h.UserRepo.GetActiveSessions(ctx.GetString("user_id"))
+	if err != nil {
+		h.Logger.Error("Error getting sessions", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error getting sessions",
+		})
+		return
+	}
+
+	current := ctx.GetString("session_id")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+
+	ctx.JSON(http.StatusOK, models.Sessions{
+		Sessions: sessions,
+	})
+}
+
+// @Summary Revoke session
+// @Description Revoke one session of the authenticated user
+// @Tags Sessions
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} models.Success
+// @Failure 401 {object} models.Errors
+// @Failure 404 {object} models.Errors
+// @Failure 500 {object} models.Errors
+// @Router /api/v1/sessions/{id} [delete]
+func (h *Handler) RevokeSessionHandler(ctx *gin.Context) {
+	err := h.UserRepo.RevokeSession(ctx.Param("id"), ctx.GetString("user_id"))
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, models.Errors{
+			Message: "session not found",
+		})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error revoke session", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error revoke session",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Success{
+		Message: "session revoked successfully",
+	})
+}
+
+// @Summary Revoke other sessions
+// @Description Revoke every session of the authenticated user except the current one
+// @Tags Sessions
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} models.Success
+// @Failure 401 {object} models.Errors
+// @Failure 500 {object} models.Errors
+// @Router /api/v1/sessions/revoke-others [post]
+func (h *Handler) RevokeOtherSessionsHandler(ctx *gin.Context) {
+	current := ctx.GetString("session_id")
+	if current == "" {
+		ctx.JSON(http.StatusUnauthorized, models.Errors{
+			Message: "token is not bound to a session",
+		})
+		return
+	}
+
+	_, err := h.UserRepo.RevokeOtherSessions(ctx.GetString("user_id"), current)
+	if err != nil {
+		h.Logger.Error("Error revoke other sessions", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error revoke other sessions",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Success{
+		Message: "other sessions revoked successfully",
+	})
+}
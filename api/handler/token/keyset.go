@@ -0,0 +1,258 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Key statuses in the manifest. A "next" key is already published in the JWKS
+// so that verifiers can cache it before it starts signing. The "active" key
+// signs new tokens. "retired" keys only verify tokens issued before rotation.
+const (
+	KeyStatusNext    = "next"
+	KeyStatusActive  = "active"
+	KeyStatusRetired = "retired"
+)
+
+const manifestFile = "keys.json"
+
+// KeyEntry describes one key file of the key set.
+type KeyEntry struct {
+	Kid       string     `json:"kid"`
+	File      string     `json:"file"`
+	Alg       string     `json:"alg"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
+
+// expired reports whether a retired key has outlived every token it signed.
+func (e KeyEntry) expired(now time.Time) bool {
+	return e.Status == KeyStatusRetired && e.RetiredAt != nil && now.After(e.RetiredAt.Add(VerificationWindow))
+}
+
+type KeyManifest struct {
+	Keys []KeyEntry `json:"keys"`
+}
+
+// KeySet holds the active signing key and every key still accepted for
+// verification, indexed by kid.
+type KeySet struct {
+	Active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// LoadKeySet reads the manifest and key files from dir. Retired keys whose
+// verification window has passed are skipped.
+func LoadKeySet(dir string) (*KeySet, error) {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &KeySet{keys: map[string]*SigningKey{}}
+	now := time.Now()
+	for _, entry := range manifest.Keys {
+		if entry.expired(now) {
+			continue
+		}
+
+		key, err := LoadSigningKey(filepath.Join(dir, entry.File))
+		if err != nil {
+			return nil, fmt.Errorf("load key %s: %w", entry.Kid, err)
+		}
+		if key.Kid != entry.Kid {
+			return nil, fmt.Errorf("key file %s does not match kid %s", entry.File, entry.Kid)
+		}
+
+		set.keys[key.Kid] = key
+		if entry.Status == KeyStatusActive {
+			set.Active = key
+		}
+	}
+
+	if set.Active == nil {
+		return nil, errors.New("key set has no active signing key")
+	}
+
+	return set, nil
+}
+
+func (s *KeySet) Lookup(kid string) (*SigningKey, bool) {
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (s *KeySet) JWKS() *JWKS {
+	jwks := &JWKS{Keys: []JWK{}}
+	for _, key := range s.keys {
+		jwks.Keys = append(jwks.Keys, key.JWK())
+	}
+	return jwks
+}
+
+func ReadManifest(dir string) (*KeyManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest KeyManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+// writeManifest replaces the manifest atomically so that running services
+// never read a half written file.
+func writeManifest(dir string, manifest *KeyManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(dir, manifestFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, manifestFile))
+}
+
+// GenerateKey creates a new private key in dir and adds it to the manifest
+// with the "next" status. alg is either "EdDSA" or "RS256".
+func GenerateKey(dir, alg string) (*KeyEntry, error) {
+	var private interface{}
+	var err error
+	switch alg {
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := NewSigningKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	manifest, err := ReadManifest(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		manifest = &KeyManifest{}
+	} else if err != nil {
+		return nil, err
+	}
+
+	entry := KeyEntry{
+		Kid:       key.Kid,
+		File:      key.Kid + ".pem",
+		Alg:       alg,
+		Status:    KeyStatusNext,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	block := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, entry.File), block, 0o600); err != nil {
+		return nil, err
+	}
+
+	manifest.Keys = append(manifest.Keys, entry)
+	if err := writeManifest(dir, manifest); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// PromoteKey makes kid the active signing key. The previously active key is
+// retired and keeps verifying tokens for VerificationWindow.
+func PromoteKey(dir, kid string) error {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	now := time.Now().UTC()
+	for i := range manifest.Keys {
+		entry := &manifest.Keys[i]
+		switch {
+		case entry.Kid == kid:
+			if entry.Status == KeyStatusRetired {
+				return fmt.Errorf("key %s is retired", kid)
+			}
+			entry.Status = KeyStatusActive
+			found = true
+		case entry.Status == KeyStatusActive:
+			entry.Status = KeyStatusRetired
+			entry.RetiredAt = &now
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("key %s not found", kid)
+	}
+
+	return writeManifest(dir, manifest)
+}
+
+// PruneKeys removes retired keys whose tokens have all expired and returns
+// their kids.
+func PruneKeys(dir string) ([]string, error) {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		kept    []KeyEntry
+		expired []KeyEntry
+	)
+	now := time.Now()
+	for _, entry := range manifest.Keys {
+		if entry.expired(now) {
+			expired = append(expired, entry)
+		} else {
+			kept = append(kept, entry)
+		}
+	}
+
+	manifest.Keys = kept
+	if err := writeManifest(dir, manifest); err != nil {
+		return nil, err
+	}
+
+	var pruned []string
+	for _, entry := range expired {
+		if err := os.Remove(filepath.Join(dir, entry.File)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return pruned, err
+		}
+		pruned = append(pruned, entry.Kid)
+	}
+
+	return pruned, nil
+}
@@ -0,0 +1,94 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	first, err := GenerateKey(dir, "EdDSA")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A key that is only generated is published but does not sign yet
+	_, err = LoadKeySet(dir)
+	assert.Error(t, err)
+
+	if err := PromoteKey(dir, first.Kid); err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := LoadKeySet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, first.Kid, set.Active.Kid)
+
+	oldToken, err := signWithKey(set.Active, testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := GenerateKey(dir, "RS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := PromoteKey(dir, second.Kid); err != nil {
+		t.Fatal(err)
+	}
+
+	set, err = LoadKeySet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, second.Kid, set.Active.Kid)
+	assert.Len(t, set.JWKS().Keys, 2)
+
+	lookup := func(kid string) (*SigningKey, error) {
+		key, _ := set.Lookup(kid)
+		return key, nil
+	}
+
+	// Tokens signed before the rotation are still accepted
+	_, err = parseWithKeys(lookup, oldToken)
+	assert.NoError(t, err)
+
+	pruned, err := PruneKeys(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, pruned)
+
+	// Once the verification window is over the retired key is pruned
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retiredAt := time.Now().Add(-VerificationWindow - time.Minute)
+	for i := range manifest.Keys {
+		if manifest.Keys[i].Kid == first.Kid {
+			manifest.Keys[i].RetiredAt = &retiredAt
+		}
+	}
+	if err := writeManifest(dir, manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	pruned, err = PruneKeys(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{first.Kid}, pruned)
+
+	set, err = LoadKeySet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ok := set.Lookup(first.Kid)
+	assert.False(t, ok)
+}
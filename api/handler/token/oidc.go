@@ -0,0 +1,53 @@
+package token
+
+import (
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// IDClaims are the claims of an OpenID Connect ID token. Profile and email
+// claims are only set when the matching scope was granted.
+type IDClaims struct {
+	Nonce             string `json:"nonce,omitempty"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     *bool  `json:"email_verified,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Name              string `json:"name,omitempty"`
+	jwt.StandardClaims
+}
+
+// GenerateIDToken signs an ID token for the client in claims.Audience with
+// the active key of the key set. The token lives as long as an access token.
+func GenerateIDToken(claims IDClaims) (string, error) {
+	set, err := keys.get(false)
+	if err != nil {
+		return "", err
+	}
+
+	claims.IssuedAt = time.Now().Unix()
+	claims.ExpiresAt = time.Now().Add(AccessTokenTTL).Unix()
+
+	return signWithKey(set.Active, claims)
+}
+
+// SigningAlgorithms returns the algorithms of the keys in the JWKS, for the
+// OpenID Connect discovery document.
+func SigningAlgorithms() ([]string, error) {
+	set, err := keys.get(false)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	algs := []string{}
+	for _, key := range set.keys {
+		alg := key.Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			algs = append(algs, alg)
+		}
+	}
+
+	return algs, nil
+}
@@ -3,44 +3,203 @@ package token
 import (
 	"auth-service/config"
 	"auth-service/models"
+	"auth-service/pkg"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
 type Claims struct {
-	UserId   string
-	Username string
-	Email    string
+	UserId    string
+	Username  string
+	Email     string
+	SessionId string
+	Role      string
+	ClientId  string
+	Scope     string
+	Type      string
 	jwt.StandardClaims
 }
 
-func GenerateAccessJWT(signUp *models.LoginResponse) (string, error) {
-	cfg := config.Load()
+// Token types. ID tokens, "mfa pending" and email verification tokens are
+// signed with the same keys as access tokens, so the type keeps them from
+// being accepted as one.
+const (
+	TypeAccess            = "access"
+	TypeMFAPending        = "mfa_pending"
+	TypeEmailVerification = "email_verification"
+)
+
+// MFATokenTTL is how long the user has to enter the second factor.
+const MFATokenTTL = 5 * time.Minute
+
+// AccessTokenTTL is how long an access token stays valid.
+const AccessTokenTTL = 30 * time.Minute
+
+// ClientTokenTTL is how long a client_credentials token stays valid. These
+// tokens cannot be revoked through a session, so they are kept short.
+const ClientTokenTTL = 10 * time.Minute
+
+// EmailVerificationTTL is how long the link in a verification email works.
+const EmailVerificationTTL = 24 * time.Hour
+
+// VerificationWindow is how long a retired key keeps verifying tokens: the
+// lifetime of the longest lived token it may have signed, which is the email
+// verification token.
+const VerificationWindow = EmailVerificationTTL
+
+// keyReloadInterval limits how often the key manifest is checked for changes.
+const keyReloadInterval = 10 * time.Second
+
+var keys keyStore
+
+// keyStore caches the key set of JWT_KEYS_DIR and reloads it when the
+// manifest changes, so keys rotated by cmd/keys are picked up without a
+// restart.
+type keyStore struct {
+	mu        sync.Mutex
+	set       *KeySet
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func (s *keyStore) get(force bool) (*KeySet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.set != nil && !force && time.Since(s.checkedAt) < keyReloadInterval {
+		return s.set, nil
+	}
+	if s.set != nil && force && time.Since(s.checkedAt) < time.Second {
+		return s.set, nil
+	}
+	s.checkedAt = time.Now()
+
+	dir := config.Load().JWT_KEYS_DIR
+	info, err := os.Stat(filepath.Join(dir, manifestFile))
+	if err != nil {
+		if s.set != nil {
+			return s.set, nil
+		}
+		return nil, err
+	}
+
+	if s.set != nil && info.ModTime().Equal(s.modTime) {
+		return s.set, nil
+	}
+
+	set, err := LoadKeySet(dir)
+	if err != nil {
+		if s.set != nil {
+			return s.set, nil
+		}
+		return nil, err
+	}
+
+	s.set = set
+	s.modTime = info.ModTime()
+	return s.set, nil
+}
+
+// verificationKey returns the key for kid, reloading the key set once if the
+// kid is unknown (another replica may have promoted a new key already).
+func verificationKey(kid string) (*SigningKey, error) {
+	set, err := keys.get(false)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, ok := set.Lookup(kid); ok {
+		return key, nil
+	}
+
+	set, err = keys.get(true)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, ok := set.Lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// GenerateAccessJWT issues an access token for a session. Sessions started
+// through OAuth carry their client and granted scope into the token.
+func GenerateAccessJWT(signUp *models.LoginResponse, session *models.Session) (string, error) {
+	set, err := keys.get(false)
+	if err != nil {
+		return "", err
+	}
 
 	claims := Claims{
-		UserId:   signUp.ID,
-		Username: signUp.Username,
-		Email:    signUp.Email,
+		UserId:    signUp.ID,
+		Username:  signUp.Username,
+		Email:     signUp.Email,
+		SessionId: session.ID,
+		Role:      signUp.Role,
+		ClientId:  session.ClientID,
+		Scope:     session.Scope,
+		Type:      TypeAccess,
 		StandardClaims: jwt.StandardClaims{
 			IssuedAt:  time.Now().Unix(),
-			ExpiresAt: time.Now().Add(30 * time.Minute).Unix(),
+			ExpiresAt: time.Now().Add(AccessTokenTTL).Unix(),
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return signWithKey(set.Active, claims)
+}
+
+// GenerateClientJWT issues an access token for a service client acting for
+// itself. The token has no user and no session.
+func GenerateClientJWT(clientID, scope string) (string, error) {
+	set, err := keys.get(false)
+	if err != nil {
+		return "", err
+	}
+
+	claims := Claims{
+		ClientId: clientID,
+		Scope:    scope,
+		Type:     TypeAccess,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   clientID,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(ClientTokenTTL).Unix(),
+		},
+	}
+
+	return signWithKey(set.Active, claims)
+}
+
+func signWithKey(key *SigningKey, claims jwt.Claims) (string, error) {
+	accessToken := jwt.NewWithClaims(key.Method, claims)
+	accessToken.Header["kid"] = key.Kid
 
-	return accessToken.SignedString([]byte(cfg.ACCESS_TOKEN))
+	return accessToken.SignedString(key.Private)
 }
 
-func GenerateRefreshJWT(user *models.LoginResponse) (string, error) {
+func GenerateRefreshJWT(user *models.LoginResponse, sessionID string) (string, error) {
 	cfg := config.Load()
+
+	// Har bir refresh token unikal bo'lishi uchun jti qo'shiladi
+	jti, err := pkg.GenerateRandomString(16)
+	if err != nil {
+		return "", err
+	}
+
 	claims := &Claims{
-		UserId:   user.ID,
-		Username: user.Username,
-		Email:    user.Email,
+		UserId:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		SessionId: sessionID,
 		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
 			ExpiresAt: time.Now().Add(7 * 24 * time.Hour).Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
@@ -70,11 +229,103 @@ func ExtractClaims(tokenString string) (*Claims, error) {
 }
 
 func ExtractClaimsAccess(tokenString string) (*Claims, error) {
-	cfg := config.Load()
+	return parseAccess(verificationKey, tokenString)
+}
+
+func parseAccess(lookup func(kid string) (*SigningKey, error), tokenString string) (*Claims, error) {
+	return parseTyped(lookup, tokenString, TypeAccess)
+}
+
+// GenerateMFAToken issues the token returned by login when the password was
+// right but the second factor is still missing. It only identifies the user
+// to the /auth/login/mfa step.
+func GenerateMFAToken(user *models.LoginResponse) (string, error) {
+	set, err := keys.get(false)
+	if err != nil {
+		return "", err
+	}
+
+	jti, err := pkg.GenerateRandomString(16)
+	if err != nil {
+		return "", err
+	}
+
+	claims := Claims{
+		UserId: user.ID,
+		Type:   TypeMFAPending,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(MFATokenTTL).Unix(),
+		},
+	}
+
+	return signWithKey(set.Active, claims)
+}
+
+func ExtractClaimsMFA(tokenString string) (*Claims, error) {
+	return parseTyped(verificationKey, tokenString, TypeMFAPending)
+}
+
+// GenerateEmailVerificationJWT issues the token of a verification link. It is
+// bound to the address it was sent to; the jti lets it be used only once.
+func GenerateEmailVerificationJWT(userID, email string) (string, error) {
+	set, err := keys.get(false)
+	if err != nil {
+		return "", err
+	}
+
+	jti, err := pkg.GenerateRandomString(16)
+	if err != nil {
+		return "", err
+	}
+
+	claims := Claims{
+		UserId: userID,
+		Email:  email,
+		Type:   TypeEmailVerification,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(EmailVerificationTTL).Unix(),
+		},
+	}
+
+	return signWithKey(set.Active, claims)
+}
+
+func ExtractClaimsEmailVerification(tokenString string) (*Claims, error) {
+	return parseTyped(verificationKey, tokenString, TypeEmailVerification)
+}
+
+func parseTyped(lookup func(kid string) (*SigningKey, error), tokenString, tokenType string) (*Claims, error) {
+	claims, err := parseWithKeys(lookup, tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Type != tokenType {
+		return nil, fmt.Errorf("not an %s token", tokenType)
+	}
+
+	return claims, nil
+}
+
+// parseWithKeys verifies tokenString with the key returned by lookup for the
+// token's kid. The token's alg has to match the algorithm of that key.
+func parseWithKeys(lookup func(kid string) (*SigningKey, error), tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(cfg.ACCESS_TOKEN), nil
+		kid, _ := token.Header["kid"].(string)
+		key, err := lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return key.Public, nil
 	})
 
 	if err != nil {
@@ -88,6 +339,17 @@ func ExtractClaimsAccess(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+// PublicJWKS returns the public keys that verify access tokens: the active
+// key, the next key and retired keys still inside their verification window.
+func PublicJWKS() (*JWKS, error) {
+	set, err := keys.get(false)
+	if err != nil {
+		return nil, err
+	}
+
+	return set.JWKS(), nil
+}
+
 func ValidateToken(tokenStr string) (bool, error) {
 	_, err := ExtractClaimsAccess(tokenStr)
 	if err != nil {
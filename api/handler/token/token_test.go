@@ -0,0 +1,190 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func testClaims() Claims {
+	return Claims{
+		UserId:   "975799c4-bd72-43c8-b0c5-93bd9461e033",
+		Username: "diyorbek0321",
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(time.Minute).Unix(),
+		},
+	}
+}
+
+func lookupFor(keys ...*SigningKey) func(string) (*SigningKey, error) {
+	return func(kid string) (*SigningKey, error) {
+		for _, key := range keys {
+			if key.Kid == kid {
+				return key, nil
+			}
+		}
+		return nil, errors.New("unknown key id")
+	}
+}
+
+func TestSignAndParseRSA(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+
+	key, err := ParseSigningKey(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "RS256", key.Method.Alg())
+
+	signed, err := signWithKey(key, testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := parseWithKeys(lookupFor(key), signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "diyorbek0321", claims.Username)
+
+	jwk := key.JWK()
+	assert.Equal(t, "RSA", jwk.Kty)
+	assert.Equal(t, "AQAB", jwk.E)
+	assert.Equal(t, key.Kid, jwk.Kid)
+}
+
+func TestSignAndParseEdDSA(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ParseSigningKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "EdDSA", key.Method.Alg())
+
+	signed, err := signWithKey(key, testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, _, err := new(jwt.Parser).ParseUnverified(signed, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, key.Kid, parsed.Header["kid"])
+
+	_, err = parseWithKeys(lookupFor(key), signed)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "OKP", key.JWK().Kty)
+	assert.Equal(t, "Ed25519", key.JWK().Crv)
+}
+
+func TestParseRejectsOtherKeys(t *testing.T) {
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+	key, err := NewSigningKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, otherPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	other, err := NewSigningKey(otherPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := signWithKey(other, testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parseWithKeys(lookupFor(key), signed)
+	assert.Error(t, err)
+
+	// HS256 token signed with the public key bytes must not be accepted
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	hmacToken.Header["kid"] = key.Kid
+	signed, err = hmacToken.SignedString([]byte(key.Public.(ed25519.PublicKey)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parseWithKeys(lookupFor(key), signed)
+	assert.Error(t, err)
+}
+
+func TestIDTokenIsNotAccessToken(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewSigningKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	access := testClaims()
+	access.Type = TypeAccess
+	signed, err := signWithKey(key, access)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parseAccess(lookupFor(key), signed)
+	assert.NoError(t, err)
+
+	idToken, err := signWithKey(key, IDClaims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:   access.UserId,
+			Audience:  "client",
+			ExpiresAt: time.Now().Add(time.Minute).Unix(),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parseAccess(lookupFor(key), idToken)
+	assert.Error(t, err)
+}
+
+func TestMFATokenIsNotAccessToken(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewSigningKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending := testClaims()
+	pending.Type = TypeMFAPending
+	signed, err := signWithKey(key, pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = parseAccess(lookupFor(key), signed)
+	assert.Error(t, err)
+
+	claims, err := parseTyped(lookupFor(key), signed, TypeMFAPending)
+	assert.NoError(t, err)
+	assert.Equal(t, pending.UserId, claims.UserId)
+}
@@ -2,25 +2,33 @@ package handler
 
 import (
 	"auth-service/api/handler/token"
+	"auth-service/api/middleware"
+	"auth-service/auth"
+	"auth-service/config"
 	"auth-service/models"
 	"auth-service/pkg"
-	"fmt"
+	"auth-service/pkg/mailer"
+	"database/sql"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// passwordResetTTL is how long a password reset link works.
+const passwordResetTTL = 30 * time.Minute
+
 // @Summary Register a new user
-// @Description Register a new user with email and password
+// @Description Register a new user with email and password. A verification link is sent to the email
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param Register body models.RegisterRequest true "User Registration"
 // @Success 201 {object} models.RegisterResponse
-// @Failure 400 {object} models.Errors
+// @Failure 400 {object} models.PasswordPolicyError "Invalid body, or a password that breaks the password policy"
+// @Failure 429 {object} models.Errors
 // @Router /api/v1/auth/register [post]
 func (h *Handler) RegisterHandler(ctx *gin.Context) {
 	var signUp models.RegisterRequest
@@ -33,7 +41,11 @@ func (h *Handler) RegisterHandler(ctx *gin.Context) {
 		return
 	}
 
-	hashedPass, err := bcrypt.GenerateFromPassword([]byte(signUp.Password), bcrypt.DefaultCost)
+	if !h.checkPasswordPolicy(ctx, signUp.Password, signUp.Username, signUp.Email) {
+		return
+	}
+
+	hashedPass, err := h.Hasher.Hash(signUp.Password)
 	if err != nil {
 		h.Logger.Error("Error generating hashed password", "error", err.Error())
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
@@ -42,9 +54,12 @@ func (h *Handler) RegisterHandler(ctx *gin.Context) {
 		return
 	}
 
-	signUp.Password = string(hashedPass)
+	signUp.Password = hashedPass
 
-	resp, err := h.UserRepo.CreateUser(signUp)
+	// Tasdiqlash xati user bilan bitta tranzaksiyada navbatga qo'yiladi
+	resp, err := h.UserRepo.CreateUser(signUp, func(user *models.RegisterResponse) (*models.OutboxEmail, error) {
+		return verificationEmail(ctx, user.ID, user.Username, user.Email)
+	})
 	if err != nil {
 		h.Logger.Error("Error register user", "error", err.Error())
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -57,14 +72,17 @@ func (h *Handler) RegisterHandler(ctx *gin.Context) {
 }
 
 // @Summary Login a user
-// @Description Login a user with email and password
+// @Description Login a user with email and password. If two-factor authentication is on, the response is an mfa challenge instead of tokens, finished with /api/v1/auth/login/mfa. A wrong email and a wrong password get the same 401. Repeated failures slow the account down and then lock it for a while; 429 comes with Retry-After
 // @Tags Auth
 // @Accept json
 // Produce json
 // @Param Login body models.LoginRequest true "User Login"
 // @Success 200 {object} models.Token
+// @Success 202 {object} models.MFAChallenge
 // @Failure 400 {object} models.Errors
-// @Failure 404 {object} models.Errors
+// @Failure 401 {object} models.Errors
+// @Failure 403 {object} models.Errors
+// @Failure 429 {object} models.Errors
 // @Failure 500 {object} models.Errors
 // @Router /api/v1/auth/login [post]
 func (h *Handler) LoginHandler(ctx *gin.Context) {
@@ -78,53 +96,87 @@ func (h *Handler) LoginHandler(ctx *gin.Context) {
 		return
 	}
 
-	user, err := h.UserRepo.GetUserByEmail(signIn.Email)
+	email := normalizeEmail(signIn.Email)
+
+	wait, err := h.loginBlockedFor(ctx, email)
 	if err != nil {
-		h.Logger.Error("Error getting user by email", "error", err.Error())
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"Error": err.Error(),
-		})
+		h.Logger.Error("Error checking login attempts", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login"})
+		return
+	}
+	if wait > 0 {
+		abortLoginBlocked(ctx, wait)
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(signIn.Password)); err != nil {
-		h.Logger.Error("Invalid password", "error", err.Error())
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"Error": err.Error(),
-		})
+	user, err := h.UserRepo.GetUserByEmail(signIn.Email)
+	if err != nil && err != sql.ErrNoRows {
+		h.Logger.Error("Error getting user by email", "error", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login"})
 		return
 	}
 
-	accessToken, err := token.GenerateAccessJWT(user)
+	// Email topilmasa ham parol tekshiriladi, javob vaqti bir xil bo'lishi uchun
+	passwordHash := h.dummyPasswordHash()
+	if user != nil {
+		passwordHash = user.Password
+	}
+
+	ok, err := h.Hasher.Verify(signIn.Password, passwordHash)
 	if err != nil {
-		h.Logger.Error("Error generating access token:", "error", err.Error())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate access token"})
+		h.Logger.Error("Error verifying password hash", slog.String("error", err.Error()))
+	}
+	if !ok || user == nil {
+		if err := h.loginFailed(ctx, email, user); err != nil {
+			h.Logger.Error("Error counting failed login", slog.String("error", err.Error()))
+		}
+		ctx.JSON(http.StatusUnauthorized, errInvalidCredentials)
 		return
 	}
 
-	refreshToken, err := token.GenerateRefreshJWT(user)
-	if err != nil {
-		h.Logger.Error("Error generating refresh token:", "error", err.Error())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
+	h.upgradePasswordHash(user, signIn.Password)
+
+	if !user.EmailVerified && config.Load().REQUIRE_VERIFIED_EMAIL {
+		ctx.JSON(http.StatusForbidden, models.Errors{
+			Message: "email is not verified",
+		})
 		return
 	}
-	err = h.UserRepo.SaveRefreshToken(user.Username, refreshToken, time.Now().Add(7*24*time.Hour))
+
+	// 2FA yoqilgan bo'lsa tokenlar ikkinchi bosqichdan keyin beriladi.
+	// Xato urinishlar hisoblagichi ham faqat kod to'g'ri bo'lganda tozalanadi
+	if user.TwoFactorEnabled {
+		mfaToken, err := token.GenerateMFAToken(user)
+		if err != nil {
+			h.Logger.Error("Error generating mfa token", slog.String("error", err.Error()))
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+			return
+		}
+
+		ctx.JSON(http.StatusAccepted, models.MFAChallenge{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		})
+		return
+	}
+
+	if err := h.loginSucceeded(email); err != nil {
+		h.Logger.Error("Error clearing login attempts", slog.String("error", err.Error()))
+	}
+
+	newToken, err := h.startLogin(ctx, user)
 	if err != nil {
-		h.Logger.Error("error in save refresh token", slog.String("error", err.Error()))
-		ctx.JSON(http.StatusInsufficientStorage, gin.H{"error": "Faild in save refresh token"})
+		h.Logger.Error("error in start session", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
 		return
 	}
 	h.Logger.Info("user login successfully")
-	newToken := models.Token{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	}
-	
+
 	ctx.JSON(http.StatusOK, newToken)
 }
 
 // @Summary Logout user
-// @Description Logout the authenticated user
+// @Description End the current session of the authenticated user, other devices stay signed in
 // @Tags Auth
 // @Accept json
 // @Security ApiKeyAuth
@@ -135,42 +187,44 @@ func (h *Handler) LoginHandler(ctx *gin.Context) {
 // @Failure 500 {object} models.Errors
 // @Router /api/v1/auth/logout [post]
 func (h *Handler) LogoutUserHandler(ctx *gin.Context) {
-	accessToken := ctx.GetHeader("Authorization")
-
-	accessClaims, err := token.ExtractClaimsAccess(accessToken)
-	if err != nil {
-		h.Logger.Error("eror tokenni extract qilishda", slog.String("error", err.Error()))
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
-		return
+	principal := ctx.MustGet(middleware.PrincipalKey).(*auth.Principal)
+
+	// Faqat joriy sessiyani yakunlash
+	if principal.SessionID != "" {
+		err := h.UserRepo.RevokeSession(principal.SessionID, principal.UserID)
+		if err != nil && err != sql.ErrNoRows {
+			h.Logger.Error("Error revoke session", slog.String("error", err.Error()))
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
 	}
-	// Refresh tokenni bekor qilish
-	err = h.UserRepo.InvalidateRefreshToken(accessClaims.Username)
+
+	// Token muddati tugaguncha qora ro'yxatda turadi
+	err := h.RedisClient.BlacklistToken(principal.Token, time.Until(time.Unix(principal.ExpiresAt, 0)))
 	if err != nil {
-		h.Logger.Error("Error invalidate token", slog.String("error", err.Error()))
+		h.Logger.Error("Error blacklist token", slog.String("error", err.Error()))
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	h.RedisClient.BlacklistToken(accessToken, time.Duration(accessClaims.ExpiresAt))
 	ctx.JSON(http.StatusOK, models.Success{
 		Message: "user logouted successfully",
 	})
 }
 
 // @Summary Reset Password
-// @Description Userni parolini qayta tiklash
+// @Description Userni parolini qayta tiklash. A single-use reset link is emailed if the address belongs to an account; the response does not tell whether it does
 // @Tags Auth
 // @Accept json
-// @Security ApiKeyAuth
 // @Produce json
 // @Param ResetPassword body models.ResetPassword true "Reset password"
 // @Success 200 {object} models.Success
-// @Failure 404 {object} models.Errors
 // @Failure 400 {object} models.Errors
+// @Failure 429 {object} models.Errors
 // @Failure 500 {object} models.Errors
 // @Router /api/v1/auth/reset-password [post]
 func (h *Handler) ResetPasswordHandler(ctx *gin.Context) {
@@ -184,11 +238,24 @@ func (h *Handler) ResetPasswordHandler(ctx *gin.Context) {
 		return
 	}
 
-	newtoken, err := token.GenerateAccessJWT(&models.LoginResponse{
-		ID:       ctx.GetString("user-id"),
-		Username: ctx.GetString("username"),
-		Email:    ctx.GetString("email"),
-	})
+	sent := models.Success{
+		Message: "if the address belongs to an account, a reset link was sent",
+	}
+
+	user, err := h.UserRepo.GetUserByEmail(email.Email)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusOK, sent)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error getting user by email", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error reset password",
+		})
+		return
+	}
+
+	resetToken, err := pkg.GenerateRandomString(32)
 	if err != nil {
 		h.Logger.Error("Error generated token", slog.String("error", err.Error()))
 		ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -196,75 +263,209 @@ func (h *Handler) ResetPasswordHandler(ctx *gin.Context) {
 		})
 		return
 	}
-	resetLink := pkg.CreateResetLink(ctx.Request.URL.Path, newtoken)
 
-	// Email content
-	subject := "Password Reset"
-	body := "Click on the link to reset your password: " + resetLink
+	// Havola web ilovadagi yangi parol sahifasiga olib boradi
+	resetEmail, err := composeEmail(ctx, user.Email, mailer.ResetPassword, mailer.Data{
+		Username: user.Username,
+		Link:     publicLink("/reset-password", url.Values{"token": {resetToken}}),
+		Expires:  passwordResetTTL,
+	})
+	if err != nil {
+		h.Logger.Error("Error rendering reset password email", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error reset password",
+		})
+		return
+	}
 
-	// Emailni yuborish
-	err = pkg.SendEmail(email.Email, subject, body)
+	// Bazada faqat tokenning hashi saqlanadi, email esa shu tranzaksiyada navbatga qo'yiladi
+	err = h.UserRepo.CreatePasswordReset(user.ID, pkg.HashToken(resetToken), time.Now().Add(passwordResetTTL), resetEmail)
 	if err != nil {
-		h.Logger.Error("Error in send email reset password link", slog.String("error", err.Error()))
-		ctx.JSON(http.StatusInsufficientStorage, gin.H{
-			"error": err.Error(),
+		h.Logger.Error("Error saving password reset", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error reset password",
 		})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, models.Success{
-		Message: "Send email succussfully",
-	})
+	ctx.JSON(http.StatusOK, sent)
 }
 
 // @Summary Update Parol
-// @Description Parolni emailga yuborilgan linkda yangilash
+// @Description Parolni emailga yuborilgan linkda yangilash. The reset token works once, and every session of the user is ended after the password changes
 // @Tags Auth
 // @Accept json
-// @Security ApiKeyAuth
 // @Produce json
-// @Param UpdatePassword body models.UpdatePassword true "Reset Password"
-// @Param Authorization header string true "Refresh token"
+// @Param token query string true "Reset token from the email"
+// @Param UpdatePassword body models.NewPassword true "New password"
 // @Success 200 {object} models.Success
-// @Failure 400 {object} models.Errors
+// @Failure 400 {object} models.PasswordPolicyError "Invalid link, or a password that breaks the password policy"
 // @Failure 500 {object} models.Errors
 // @Router /api/v1/auth/reset-password/new-password [post]
 func (h *Handler) UpdatePasswordHandler(ctx *gin.Context) {
-	var pass models.UpdatePassword
-	accesstoken := ctx.Query("token")
-	fmt.Println(accesstoken)
-	claims, err := token.ExtractClaims(accesstoken)
+	var pass models.NewPassword
 
+	if err := ctx.ShouldBindJSON(&pass); err != nil {
+		h.Logger.Error("Error bind json")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	resetToken := ctx.Query("token")
+	if resetToken == "" {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: "reset token is required",
+		})
+		return
+	}
+
+	// Parol qoidalari username va emailni ham tekshiradi
+	user, err := h.UserRepo.GetPasswordResetUser(pkg.HashToken(resetToken))
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: "reset link is invalid or expired",
+		})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error getting password reset", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error in reset password",
+		})
+		return
+	}
+
+	if !h.checkPasswordPolicy(ctx, pass.NewPassword, user.Username, user.Email) {
+		return
+	}
+
+	hashedPass, err := h.Hasher.Hash(pass.NewPassword)
 	if err != nil {
-		h.Logger.Error("Error tokenni tekshirishda xatolik", slog.String("error", err.Error()))
+		h.Logger.Error("Error generating hashed password", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error in reset password",
+		})
+		return
+	}
+
+	userID, err := h.UserRepo.ResetPassword(pkg.HashToken(resetToken), hashedPass)
+	if err == sql.ErrNoRows {
 		ctx.JSON(http.StatusBadRequest, models.Errors{
-			Message: "tokenni tekshirishda xatolik",
+			Message: "reset link is invalid or expired",
+		})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error in reset password", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error in reset password",
 		})
 		return
 	}
-	pass.ID = claims.Id
+	h.Logger.Info("password reset, sessions revoked", slog.String("user_id", userID))
 
-	if err := ctx.ShouldBindJSON(&pass); err != nil {
+	ctx.JSON(http.StatusOK, models.Success{
+		Message: "Reset password successfully",
+	})
+}
+
+// @Summary Change password
+// @Description Change the password of the authenticated user. The current password is required, and every other session of the user is ended. Tokens issued to OAuth clients cannot change the password
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param ChangePassword body models.ChangePassword true "Current and new password"
+// @Success 200 {object} models.Success
+// @Failure 400 {object} models.PasswordPolicyError "Invalid body, wrong current password, or a password that breaks the password policy"
+// @Failure 401 {object} models.Errors
+// @Failure 403 {object} models.Errors
+// @Failure 409 {object} models.Errors
+// @Failure 429 {object} models.Errors
+// @Failure 500 {object} models.Errors
+// @Security ApiKeyAuth
+// @Router /api/v1/auth/change-password [post]
+func (h *Handler) ChangePasswordHandler(ctx *gin.Context) {
+	var req models.ChangePassword
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
 		h.Logger.Error("Error bind json")
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
-	resp, err := h.UserRepo.UpdatePassword(pass)
+
+	principal := ctx.MustGet(middleware.PrincipalKey).(*auth.Principal)
+	if principal.ClientID != "" {
+		ctx.JSON(http.StatusForbidden, models.Errors{
+			Message: "password can only be changed with a first-party token",
+		})
+		return
+	}
+	if principal.SessionID == "" {
+		ctx.JSON(http.StatusUnauthorized, models.Errors{
+			Message: "token is not bound to a session",
+		})
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(principal.UserID)
 	if err != nil {
-		h.Logger.Error("Error in reset password", slog.String("error", err.Error()))
+		h.Logger.Error("Error getting user", slog.String("error", err.Error()))
 		ctx.JSON(http.StatusInternalServerError, models.Errors{
-			Message: "Error in reset password",
+			Message: "Error change password",
+		})
+		return
+	}
+
+	ok, err := h.Hasher.Verify(req.CurrentPassword, user.Password)
+	if err != nil {
+		h.Logger.Error("Error verifying password", slog.String("error", err.Error()))
+	}
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: "current password is incorrect",
+		})
+		return
+	}
+
+	if !h.checkPasswordPolicy(ctx, req.NewPassword, user.Username, user.Email) {
+		return
+	}
+
+	hashedPass, err := h.Hasher.Hash(req.NewPassword)
+	if err != nil {
+		h.Logger.Error("Error generating hashed password", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error change password",
 		})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, resp)
+	err = h.UserRepo.ChangePassword(user.ID, user.Password, hashedPass, principal.SessionID)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusConflict, models.Errors{
+			Message: "password was changed by another request, try again",
+		})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error change password", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error change password",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Success{
+		Message: "password changed, other sessions were signed out",
+	})
 }
 
 // @Summary Refresh access token
-// @Description Refresh the access token using the refresh token
+// @Description Rotate the refresh token: a new access and refresh token pair is issued and the old refresh token is invalidated
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -272,6 +473,7 @@ func (h *Handler) UpdatePasswordHandler(ctx *gin.Context) {
 // @Success 200 {object} models.Token
 // @Failure 400 {object} models.Errors
 // @Failure 401 {object} models.Errors
+// @Failure 429 {object} models.Errors
 // @Failure 500 {object} models.Errors
 // @Security ApiKeyAuth
 // @Router /api/v1/auth/refresh [post]
@@ -284,37 +486,18 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	claims, err := token.ExtractClaims(refreshToken)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token"})
-		return
-	}
-	isValid, err := h.UserRepo.IsRefreshTokenValid(refreshToken)
-	if err != nil || !isValid {
-		h.Logger.Error("token invalid", slog.String("error", err.Error()))
+	newToken, _, err := h.rotateSession(c, refreshToken, "")
+	if err == errInvalidRefreshToken {
 		c.JSON(http.StatusUnauthorized, models.Errors{
 			Message: "token invalid",
 		})
 		return
 	}
-
-	if claims.ExpiresAt < time.Now().Unix() {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has expired"})
-		return
-	}
-
-	newAccessToken, err := token.GenerateAccessJWT(&models.LoginResponse{
-		ID:       claims.UserId,
-		Username: claims.Username,
-		Email:    claims.Email,
-	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate new access token"})
+		h.Logger.Error("error in rotate refresh token", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate refresh token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.Token{
-		AccessToken:  newAccessToken,
-		RefreshToken: refreshToken,
-	})
+	c.JSON(http.StatusOK, newToken)
 }
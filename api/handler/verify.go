@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"auth-service/api/handler/token"
+	"auth-service/config"
+	"auth-service/models"
+	"auth-service/pkg/mailer"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Verify email
+// @Description Verifies the email address of an account with the token from the verification email. Each link works once
+// @Tags Auth
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {object} models.Success
+// @Failure 400 {object} models.Errors
+// @Failure 500 {object} models.Errors
+// @Router /api/v1/auth/verify-email [get]
+func (h *Handler) VerifyEmailHandler(ctx *gin.Context) {
+	claims, err := token.ExtractClaimsEmailVerification(ctx.Query("token"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: "verification link is invalid or expired",
+		})
+		return
+	}
+
+	first, err := h.RedisClient.MarkOnce("email_verify_used:"+claims.Id, token.EmailVerificationTTL)
+	if err != nil {
+		h.Logger.Error("Error marking verification token used", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error verify email",
+		})
+		return
+	}
+	if !first {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: "verification link was already used",
+		})
+		return
+	}
+
+	verified, err := h.UserRepo.MarkEmailVerified(claims.UserId, claims.Email)
+	if err != nil {
+		h.Logger.Error("Error verify email", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error verify email",
+		})
+		return
+	}
+	if !verified {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: "verification link is no longer valid",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Success{
+		Message: "email verified successfully",
+	})
+}
+
+// @Summary Resend verification email
+// @Description Sends a new verification link. The response is the same whether or not the address belongs to an unverified account. Resends are limited per address and hour
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param Email body models.ResendVerification true "Email"
+// @Success 200 {object} models.Success
+// @Failure 400 {object} models.Errors
+// @Failure 429 {object} models.Errors
+// @Failure 500 {object} models.Errors
+// @Router /api/v1/auth/verify-email/resend [post]
+func (h *Handler) ResendVerificationHandler(ctx *gin.Context) {
+	var req models.ResendVerification
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Errors{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	attempts, err := h.RedisClient.CountAttempt("verify_resend:"+strings.ToLower(req.Email), time.Hour)
+	if err != nil {
+		h.Logger.Error("Error counting resend attempts", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error resend verification email",
+		})
+		return
+	}
+	if attempts > int64(config.Load().VERIFY_RESEND_LIMIT) {
+		ctx.JSON(http.StatusTooManyRequests, models.Errors{
+			Message: "too many verification emails, try again later",
+		})
+		return
+	}
+
+	sent := models.Success{
+		Message: "if the address belongs to an unverified account, a verification email was sent",
+	}
+
+	user, err := h.UserRepo.GetUserByEmail(req.Email)
+	if err == sql.ErrNoRows || (err == nil && user.EmailVerified) {
+		ctx.JSON(http.StatusOK, sent)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error getting user by email", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error resend verification email",
+		})
+		return
+	}
+
+	email, err := verificationEmail(ctx, user.ID, user.Username, user.Email)
+	if err == nil {
+		err = h.UserRepo.EnqueueEmail(email)
+	}
+	if err != nil {
+		h.Logger.Error("Error queueing verification email", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, models.Errors{
+			Message: "Error resend verification email",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, sent)
+}
+
+// verificationEmail builds the email with a new verification link for the
+// address.
+func verificationEmail(ctx *gin.Context, userID, username, email string) (*models.OutboxEmail, error) {
+	verifyToken, err := token.GenerateEmailVerificationJWT(userID, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return composeEmail(ctx, email, mailer.VerifyEmail, mailer.Data{
+		Username: username,
+		Link:     publicLink("/api/v1/auth/verify-email", url.Values{"token": {verifyToken}}),
+		Expires:  token.EmailVerificationTTL,
+	})
+}
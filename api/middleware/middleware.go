@@ -1,47 +1,47 @@
 package middleware
 
 import (
-	"auth-service/api/handler/token"
+	"auth-service/auth"
 	"auth-service/logs"
-	"fmt"
+	"errors"
 	"log/slog"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// PrincipalKey is the gin context key of the authenticated *auth.Principal.
+const PrincipalKey = "principal"
+
+func AuthMiddleware(verifier *auth.Verifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
+		tokenString := auth.TokenFromHeader(c.GetHeader("Authorization"))
+		if tokenString == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
 			c.Abort()
 			return
 		}
 
-		valid, err := token.ValidateToken(authHeader)
-		if err != nil || !valid {
-			c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Errorf("invalid token: %s", err))
+		claims, err := verifier.Verify(tokenString)
+		if errors.Is(err, auth.ErrInvalidToken) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
-
-		claims, err := token.ExtractClaimsAccess(authHeader)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			logs.Logger.Error("Error verifying token", slog.String("error", err.Error()))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to verify token"})
 			return
 		}
 
-		if claims.ExpiresAt < time.Now().Unix() {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has expired"})
-			c.Abort()
-			return
-		}
+		principal := auth.NewPrincipal(claims, tokenString)
 
 		// Claimsdan ma'lumotlarni kontekstga qo'shish
+		c.Set(PrincipalKey, principal)
 		c.Set("user_id", claims.UserId)
 		c.Set("username", claims.Username)
 		c.Set("email", claims.Email)
+		c.Set("session_id", claims.SessionId)
+		c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), principal))
 
 		c.Next()
 	}
@@ -61,3 +61,24 @@ func LoggerMiddleware() gin.HandlerFunc {
 		)
 	}
 }
+
+// Authorize checks the rule of the matched route in auth.HTTPPolicy. It has
+// to run after AuthMiddleware.
+func Authorize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule, ok := auth.HTTPPolicy[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "route is not allowed"})
+			return
+		}
+
+		principal, _ := c.Get(PrincipalKey)
+		p, _ := principal.(*auth.Principal)
+		if err := rule.Allow(p, c); err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Next()
+	}
+}
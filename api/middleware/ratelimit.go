@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"auth-service/auth"
+	"auth-service/logs"
+	"auth-service/ratelimit"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit applies the limit of the matched route. On authenticated routes
+// it has to run after AuthMiddleware, so requests are counted per user.
+// Requests pass if Redis cannot be reached. A nil limiter limits nothing.
+func RateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+
+		principal, _ := c.Get(PrincipalKey)
+		p, _ := principal.(*auth.Principal)
+
+		decision, err := limiter.Take(c.Request.Method+" "+c.FullPath(), p, c.ClientIP())
+		if err != nil {
+			logs.Logger.Error("Error checking rate limit", slog.String("error", err.Error()))
+			c.Next()
+			return
+		}
+		if decision == nil {
+			c.Next()
+			return
+		}
+
+		for name, value := range decision.Headers() {
+			c.Header(name, value)
+		}
+
+		if !decision.Allowed {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded, try again later"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"auth-service/api/handler"
+	"auth-service/api/middleware"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -25,14 +26,76 @@ func NewRouter(handle *handler.Handler) *gin.Engine {
 	// Swagger endpointini sozlash
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	router.GET("/.well-known/jwks.json", handle.JWKSHandler)
+	router.GET("/.well-known/openid-configuration", handle.OpenIDConfigurationHandler)
+
+	// Rate limit autentifikatsiyadan keyin turadi, shunda user bo'yicha sanaladi
+	rateLimit := middleware.RateLimit(handle.Limiter)
+
+	userinfo := router.Group("/userinfo")
+	userinfo.Use(middleware.AuthMiddleware(handle.Verifier), middleware.Authorize(), rateLimit)
+	{
+		userinfo.GET("", handle.UserInfoHandler)
+		userinfo.POST("", handle.UserInfoHandler)
+	}
+
+	oauth := router.Group("/oauth")
+	oauth.Use(rateLimit)
+	{
+		oauth.POST("/introspect", handle.IntrospectHandler)
+		oauth.POST("/token", handle.TokenHandler)
+	}
+
+	consent := router.Group("/oauth")
+	consent.Use(middleware.AuthMiddleware(handle.Verifier), middleware.Authorize(), rateLimit)
+	{
+		consent.GET("/authorize", handle.AuthorizeHandler)
+		consent.POST("/authorize", handle.AuthorizeConsentHandler)
+	}
+
 	auth := router.Group("/api/v1/auth")
+	auth.Use(rateLimit)
 	{
 		auth.POST("/register", handle.RegisterHandler)
 		auth.POST("/login", handle.LoginHandler)
+		auth.POST("/login/mfa", handle.LoginMFAHandler)
+		auth.GET("/verify-email", handle.VerifyEmailHandler)
+		auth.POST("/verify-email/resend", handle.ResendVerificationHandler)
+		auth.GET("/unlock", handle.UnlockAccountHandler)
 		auth.POST("/reset-password", handle.ResetPasswordHandler)
 		auth.POST("/reset-password/new-password", handle.UpdatePasswordHandler)
 		auth.POST("/refresh", handle.RefreshToken)
-		auth.POST("/logout", handle.LogoutUserHandler)
+	}
+
+	account := router.Group("/api/v1/auth")
+	account.Use(middleware.AuthMiddleware(handle.Verifier), middleware.Authorize(), rateLimit)
+	{
+		account.POST("/change-password", handle.ChangePasswordHandler)
+		account.POST("/logout", handle.LogoutUserHandler)
+	}
+
+	twoFactor := router.Group("/api/v1/auth/2fa")
+	twoFactor.Use(middleware.AuthMiddleware(handle.Verifier), middleware.Authorize(), rateLimit)
+	{
+		twoFactor.POST("/enroll", handle.EnrollTOTPHandler)
+		twoFactor.POST("/confirm", handle.ConfirmTOTPHandler)
+		twoFactor.POST("/disable", handle.DisableTOTPHandler)
+	}
+
+	sessions := router.Group("/api/v1/sessions")
+	sessions.Use(middleware.AuthMiddleware(handle.Verifier), middleware.Authorize(), rateLimit)
+	{
+		sessions.GET("", handle.ListSessionsHandler)
+		sessions.DELETE("/:id", handle.RevokeSessionHandler)
+		sessions.POST("/revoke-others", handle.RevokeOtherSessionsHandler)
+	}
+
+	admin := router.Group("/api/v1/admin")
+	admin.Use(middleware.AuthMiddleware(handle.Verifier), middleware.Authorize(), rateLimit)
+	{
+		admin.POST("/oauth/clients", handle.CreateOAuthClientHandler)
+		admin.GET("/outbox", handle.ListOutboxHandler)
+		admin.POST("/outbox/:id/retry", handle.RetryOutboxHandler)
 	}
 
 	return router
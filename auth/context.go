@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"auth-service/api/handler/token"
+	"context"
+	"strings"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID    string
+	Username  string
+	Email     string
+	SessionID string
+	Role      string
+	ClientID  string
+	Scope     string
+	Token     string
+	ExpiresAt int64
+}
+
+func NewPrincipal(claims *token.Claims, tokenString string) *Principal {
+	return &Principal{
+		UserID:    claims.UserId,
+		Username:  claims.Username,
+		Email:     claims.Email,
+		SessionID: claims.SessionId,
+		Role:      claims.Role,
+		ClientID:  claims.ClientId,
+		Scope:     claims.Scope,
+		Token:     tokenString,
+		ExpiresAt: claims.ExpiresAt,
+	}
+}
+
+// Machine reports whether the principal is a service client acting for
+// itself (client_credentials grant) rather than for a user.
+func (p *Principal) Machine() bool {
+	return p.UserID == "" && p.ClientID != ""
+}
+
+type principalKey struct{}
+
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
+
+// TokenFromHeader returns the token of an Authorization header value. Both a
+// bare token and the "Bearer <token>" form are accepted.
+func TokenFromHeader(header string) string {
+	header = strings.TrimSpace(header)
+	if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return header
+}
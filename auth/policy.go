@@ -0,0 +1,172 @@
+package auth
+
+import (
+	pb "auth-service/generated/user"
+	"errors"
+)
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var roleRank = map[string]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// HasRole reports whether role is at least as strong as required.
+func HasRole(role, required string) bool {
+	return roleRank[role] > 0 && roleRank[role] >= roleRank[required]
+}
+
+var ErrForbidden = errors.New("permission denied")
+
+// Rule describes who may call an operation.
+type Rule struct {
+	// Public operations need no access token at all.
+	Public bool
+	// Role is the minimum role of the caller.
+	Role string
+	// Owner returns the id of the user the request acts on. When set, only
+	// that user or an admin may call the operation.
+	Owner func(req interface{}) string
+	// Scopes lets tokens issued to an OAuth client call the operation with
+	// any one of these scopes. Service clients need nothing else; tokens a
+	// user delegated to an application must also pass the role and owner
+	// checks. Operations without scopes are closed to OAuth clients.
+	Scopes []string
+	// FirstParty operations cannot be called with a token a user delegated
+	// to an application, whatever its scopes.
+	FirstParty bool
+	// Service operations can only be called by service clients.
+	Service bool
+}
+
+// Allow checks the rule for principal. req is the gRPC request message, or the
+// *gin.Context for HTTP routes.
+func (r Rule) Allow(principal *Principal, req interface{}) error {
+	if r.Public {
+		return nil
+	}
+	if principal == nil {
+		return ErrForbidden
+	}
+	if r.Service && !principal.Machine() {
+		return ErrForbidden
+	}
+	if principal.ClientID != "" {
+		if r.FirstParty && !principal.Machine() {
+			return ErrForbidden
+		}
+		if !r.hasScope(principal.Scope) {
+			return ErrForbidden
+		}
+		if principal.Machine() {
+			return nil
+		}
+	}
+	if !HasRole(principal.Role, r.Role) {
+		return ErrForbidden
+	}
+	if r.Owner != nil && !HasRole(principal.Role, RoleAdmin) {
+		if req == nil || r.Owner(req) != principal.UserID {
+			return ErrForbidden
+		}
+	}
+	return nil
+}
+
+func (r Rule) hasScope(scope string) bool {
+	for _, want := range r.Scopes {
+		if HasScope(scope, want) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	readScopes  = []string{ScopeUsersRead, ScopeUsersAdmin}
+	adminScopes = []string{ScopeUsersAdmin}
+)
+
+// GRPCPolicy maps every AuthService method to its rule. Methods missing from
+// the map are denied.
+var GRPCPolicy = map[string]Rule{
+	"/auth_service.AuthService/UserInfo":       {Role: RoleUser, Scopes: readScopes},
+	"/auth_service.AuthService/GetUserProfile": {Role: RoleUser, Scopes: readScopes},
+	"/auth_service.AuthService/UpdateUserProfile": {Role: RoleUser, Scopes: adminScopes, FirstParty: true, Owner: func(req interface{}) string {
+		return req.(*pb.UpdateProfileRequest).Id
+	}},
+	"/auth_service.AuthService/ListUsers": {Role: RoleUser, Scopes: readScopes},
+	"/auth_service.AuthService/DeleteUser": {Role: RoleUser, Scopes: adminScopes, FirstParty: true, Owner: func(req interface{}) string {
+		return req.(*pb.DeleteUserRequest).Id
+	}},
+	"/auth_service.AuthService/GetUserActivity": {Role: RoleUser, Scopes: readScopes},
+	"/auth_service.AuthService/FollowUser": {Role: RoleUser, Scopes: adminScopes, Owner: func(req interface{}) string {
+		return req.(*pb.FollowUserRequest).FollowerId
+	}},
+	"/auth_service.AuthService/ListFollowers": {Role: RoleUser, Scopes: readScopes},
+	"/auth_service.AuthService/UnfollowUser": {Role: RoleUser, Scopes: adminScopes, Owner: func(req interface{}) string {
+		return req.(*pb.UnfollowUserRequest).FollowerId
+	}},
+	"/auth_service.AuthService/ListFollowing":   {Role: RoleUser, Scopes: readScopes},
+	"/auth_service.AuthService/GetRelationship": {Role: RoleUser, Scopes: readScopes},
+	"/auth_service.AuthService/CheckFollows":    {Role: RoleUser, Scopes: readScopes},
+	"/auth_service.AuthService/BlockUser": {Role: RoleUser, Scopes: adminScopes, Owner: func(req interface{}) string {
+		return req.(*pb.BlockUserRequest).BlockerId
+	}},
+	"/auth_service.AuthService/UnblockUser": {Role: RoleUser, Scopes: adminScopes, Owner: func(req interface{}) string {
+		return req.(*pb.UnblockUserRequest).BlockerId
+	}},
+	"/auth_service.AuthService/MuteUser": {Role: RoleUser, Scopes: adminScopes, Owner: func(req interface{}) string {
+		return req.(*pb.MuteUserRequest).MuterId
+	}},
+	"/auth_service.AuthService/UnmuteUser": {Role: RoleUser, Scopes: adminScopes, Owner: func(req interface{}) string {
+		return req.(*pb.UnmuteUserRequest).MuterId
+	}},
+	"/auth_service.AuthService/IsBlocked": {Role: RoleUser, Scopes: readScopes, Owner: func(req interface{}) string {
+		return req.(*pb.IsBlockedRequest).UserId
+	}},
+	"/auth_service.AuthService/SetAccountPrivacy": {Role: RoleUser, Scopes: adminScopes, Owner: func(req interface{}) string {
+		return req.(*pb.SetAccountPrivacyRequest).UserId
+	}},
+	"/auth_service.AuthService/ListFollowRequests": {Role: RoleUser, Scopes: readScopes, Owner: func(req interface{}) string {
+		return req.(*pb.ListFollowRequestsRequest).UserId
+	}},
+	"/auth_service.AuthService/ApproveFollowRequest": {Role: RoleUser, Scopes: adminScopes, Owner: func(req interface{}) string {
+		return req.(*pb.ApproveFollowRequestRequest).UserId
+	}},
+	"/auth_service.AuthService/RejectFollowRequest": {Role: RoleUser, Scopes: adminScopes, Owner: func(req interface{}) string {
+		return req.(*pb.RejectFollowRequestRequest).UserId
+	}},
+	"/auth_service.AuthService/SuggestUsers": {Role: RoleUser, Scopes: readScopes, Owner: func(req interface{}) string {
+		return req.(*pb.SuggestUsersRequest).UserId
+	}},
+	// Used by other services to check the token of their own caller
+	"/auth_service.AuthService/ValidateToken": {Service: true, Scopes: []string{ScopeTokensIntrospect}},
+}
+
+// HTTPPolicy maps "METHOD /route/pattern" of authenticated routes to their
+// rule. Routes missing from the map are denied.
+var HTTPPolicy = map[string]Rule{
+	"POST /api/v1/auth/logout":            {Role: RoleUser},
+	"POST /api/v1/auth/change-password":   {Role: RoleUser, FirstParty: true},
+	"POST /api/v1/auth/2fa/enroll":        {Role: RoleUser, FirstParty: true},
+	"POST /api/v1/auth/2fa/confirm":       {Role: RoleUser, FirstParty: true},
+	"POST /api/v1/auth/2fa/disable":       {Role: RoleUser, FirstParty: true},
+	"GET /api/v1/sessions":                {Role: RoleUser, FirstParty: true},
+	"DELETE /api/v1/sessions/:id":         {Role: RoleUser, FirstParty: true},
+	"POST /api/v1/sessions/revoke-others": {Role: RoleUser, FirstParty: true},
+	// Consent is given by the user themselves, never by an application
+	"GET /oauth/authorize":                {Role: RoleUser, FirstParty: true},
+	"POST /oauth/authorize":               {Role: RoleUser, FirstParty: true},
+	"GET /userinfo":                       {Role: RoleUser, Scopes: []string{"openid"}},
+	"POST /userinfo":                      {Role: RoleUser, Scopes: []string{"openid"}},
+	"POST /api/v1/admin/oauth/clients":    {Role: RoleAdmin},
+	"GET /api/v1/admin/outbox":            {Role: RoleAdmin},
+	"POST /api/v1/admin/outbox/:id/retry": {Role: RoleAdmin},
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	pb "auth-service/generated/user"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasRole(t *testing.T) {
+	assert.True(t, HasRole(RoleAdmin, RoleUser))
+	assert.True(t, HasRole(RoleModerator, RoleModerator))
+	assert.False(t, HasRole(RoleUser, RoleModerator))
+	assert.False(t, HasRole("", RoleUser))
+}
+
+func TestOwnerRule(t *testing.T) {
+	rule := GRPCPolicy["/auth_service.AuthService/DeleteUser"]
+	req := &pb.DeleteUserRequest{Id: "975799c4-bd72-43c8-b0c5-93bd9461e033"}
+
+	owner := &Principal{UserID: "975799c4-bd72-43c8-b0c5-93bd9461e033", Role: RoleUser}
+	other := &Principal{UserID: "9b0cf2c8-308c-4896-a737-511bff1bb991", Role: RoleUser}
+	moderator := &Principal{UserID: "9b0cf2c8-308c-4896-a737-511bff1bb991", Role: RoleModerator}
+	admin := &Principal{UserID: "e1b9af75-931d-4d3b-acd7-a00e2571fa92", Role: RoleAdmin}
+
+	assert.NoError(t, rule.Allow(owner, req))
+	assert.ErrorIs(t, rule.Allow(other, req), ErrForbidden)
+	assert.ErrorIs(t, rule.Allow(moderator, req), ErrForbidden)
+	assert.NoError(t, rule.Allow(admin, req))
+	assert.ErrorIs(t, rule.Allow(nil, req), ErrForbidden)
+}
+
+func TestEveryMethodHasRule(t *testing.T) {
+	for _, method := range pb.AuthService_ServiceDesc.Methods {
+		fullMethod := "/" + pb.AuthService_ServiceDesc.ServiceName + "/" + method.MethodName
+		_, ok := GRPCPolicy[fullMethod]
+		assert.True(t, ok, "no policy rule for %s", fullMethod)
+	}
+}
+
+func TestMachineScopes(t *testing.T) {
+	read := &Principal{ClientID: "stories", Scope: ScopeUsersRead}
+	admin := &Principal{ClientID: "communication", Scope: ScopeUsersRead + " " + ScopeUsersAdmin}
+	none := &Principal{ClientID: "itineraries"}
+
+	list := GRPCPolicy["/auth_service.AuthService/ListUsers"]
+	assert.NoError(t, list.Allow(read, &pb.ListUsersRequest{}))
+	assert.NoError(t, list.Allow(admin, &pb.ListUsersRequest{}))
+	assert.ErrorIs(t, list.Allow(none, &pb.ListUsersRequest{}), ErrForbidden)
+
+	del := GRPCPolicy["/auth_service.AuthService/DeleteUser"]
+	req := &pb.DeleteUserRequest{Id: "975799c4-bd72-43c8-b0c5-93bd9461e033"}
+	assert.ErrorIs(t, del.Allow(read, req), ErrForbidden)
+	assert.NoError(t, del.Allow(admin, req))
+
+	// HTTP routes have no scopes, service clients cannot use them
+	assert.ErrorIs(t, HTTPPolicy["GET /api/v1/sessions"].Allow(admin, nil), ErrForbidden)
+
+	// Only service clients with tokens:introspect validate tokens
+	validate := GRPCPolicy["/auth_service.AuthService/ValidateToken"]
+	assert.NoError(t, validate.Allow(&Principal{ClientID: "stories", Scope: ScopeTokensIntrospect}, nil))
+	assert.ErrorIs(t, validate.Allow(admin, nil), ErrForbidden)
+	assert.ErrorIs(t, validate.Allow(&Principal{UserID: "975799c4-bd72-43c8-b0c5-93bd9461e033", Role: RoleAdmin}, nil), ErrForbidden)
+	assert.ErrorIs(t, validate.Allow(nil, nil), ErrForbidden)
+}
+
+func TestDelegatedTokens(t *testing.T) {
+	owner := "975799c4-bd72-43c8-b0c5-93bd9461e033"
+	// A token the user granted to a third-party application
+	delegated := &Principal{UserID: owner, Role: RoleUser, ClientID: "trip-planner", Scope: "openid profile"}
+
+	// First-party actions are closed to applications whatever the scope
+	del := GRPCPolicy["/auth_service.AuthService/DeleteUser"]
+	assert.ErrorIs(t, del.Allow(delegated, &pb.DeleteUserRequest{Id: owner}), ErrForbidden)
+	broad := *delegated
+	broad.Scope = ScopeUsersAdmin
+	assert.ErrorIs(t, del.Allow(&broad, &pb.DeleteUserRequest{Id: owner}), ErrForbidden)
+
+	for _, route := range []string{"POST /api/v1/auth/2fa/disable", "DELETE /api/v1/sessions/:id", "POST /oauth/authorize"} {
+		assert.ErrorIs(t, HTTPPolicy[route].Allow(delegated, nil), ErrForbidden, route)
+	}
+
+	// Other operations need one of their scopes
+	list := GRPCPolicy["/auth_service.AuthService/ListUsers"]
+	assert.ErrorIs(t, list.Allow(delegated, &pb.ListUsersRequest{}), ErrForbidden)
+	assert.NoError(t, HTTPPolicy["GET /userinfo"].Allow(delegated, nil))
+
+	// and stay bound to the user who granted them
+	scoped := *delegated
+	scoped.Scope = ScopeUsersAdmin
+	follow := GRPCPolicy["/auth_service.AuthService/FollowUser"]
+	assert.NoError(t, follow.Allow(&scoped, &pb.FollowUserRequest{FollowerId: owner}))
+	assert.ErrorIs(t, follow.Allow(&scoped, &pb.FollowUserRequest{FollowerId: "9b0cf2c8-308c-4896-a737-511bff1bb991"}), ErrForbidden)
+
+	// First-party tokens keep full rights
+	user := &Principal{UserID: owner, Role: RoleUser}
+	assert.NoError(t, del.Allow(user, &pb.DeleteUserRequest{Id: owner}))
+}
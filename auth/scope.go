@@ -0,0 +1,28 @@
+package auth
+
+import "strings"
+
+// OAuth scopes of service clients using the client_credentials grant.
+const (
+	ScopeUsersRead        = "users:read"
+	ScopeUsersAdmin       = "users:admin"
+	ScopeTokensIntrospect = "tokens:introspect"
+)
+
+// UserScopes can be granted by a user to an application through the
+// authorization code grant.
+var UserScopes = []string{"openid", "profile", "email"}
+
+// ClientScopes can be given to service clients, which act for themselves
+// rather than for a user.
+var ClientScopes = []string{ScopeUsersRead, ScopeUsersAdmin, ScopeTokensIntrospect}
+
+// HasScope reports whether the space separated scope list contains want.
+func HasScope(scope, want string) bool {
+	for _, s := range strings.Fields(scope) {
+		if s == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"auth-service/api/handler/token"
+	"auth-service/storage/postgres"
+	"auth-service/storage/redis"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidToken is returned for tokens that must be treated as inactive.
+// The more specific errors below wrap it.
+var ErrInvalidToken = errors.New("invalid token")
+
+var (
+	ErrTokenRevoked = fmt.Errorf("%w: token has been revoked", ErrInvalidToken)
+	ErrSessionEnded = fmt.Errorf("%w: session has ended", ErrInvalidToken)
+)
+
+// Verifier checks access tokens for every transport: signature and expiry,
+// the logout blacklist in Redis and the state of the session the token was
+// issued for.
+type Verifier struct {
+	UserRepo    *postgres.UserRepo
+	RedisClient *redis.RedisClient
+}
+
+func NewVerifier(userRepo *postgres.UserRepo, redisClient *redis.RedisClient) *Verifier {
+	return &Verifier{
+		UserRepo:    userRepo,
+		RedisClient: redisClient,
+	}
+}
+
+// Verify returns the claims of an active token. Errors wrapping
+// ErrInvalidToken mean the token is not active; any other error is a failure
+// to reach Redis or Postgres.
+func (v *Verifier) Verify(tokenString string) (*token.Claims, error) {
+	claims, err := token.ExtractClaimsAccess(tokenString)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	blacklisted, err := v.RedisClient.IsTokenBlacklisted(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if blacklisted {
+		return nil, ErrTokenRevoked
+	}
+
+	if claims.SessionId != "" {
+		session, err := v.UserRepo.GetSession(claims.SessionId)
+		if err == sql.ErrNoRows {
+			return nil, ErrSessionEnded
+		}
+		if err != nil {
+			return nil, err
+		}
+		if session.Revoked {
+			return nil, ErrSessionEnded
+		}
+	}
+
+	return claims, nil
+}
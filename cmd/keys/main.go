@@ -0,0 +1,104 @@
+// Command keys manages the access token signing keys in JWT_KEYS_DIR.
+//
+//	go run ./cmd/keys list
+//	go run ./cmd/keys generate [-alg EdDSA|RS256]
+//	go run ./cmd/keys promote <kid>
+//	go run ./cmd/keys prune
+//	go run ./cmd/keys rotate [-alg EdDSA|RS256]
+//
+// rotate generates a new key, promotes it and prunes expired keys in one step.
+// Running services pick up the new manifest without a restart.
+package main
+
+import (
+	"auth-service/api/handler/token"
+	"auth-service/config"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	dir := config.Load().JWT_KEYS_DIR
+
+	cmd, args := os.Args[1], os.Args[2:]
+	switch cmd {
+	case "list":
+		list(dir)
+	case "generate":
+		generate(dir, args)
+	case "promote":
+		if len(args) != 1 {
+			usage()
+		}
+		if err := token.PromoteKey(dir, args[0]); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("promoted", args[0])
+	case "prune":
+		prune(dir)
+	case "rotate":
+		entry := generate(dir, args)
+		if err := token.PromoteKey(dir, entry.Kid); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("promoted", entry.Kid)
+		prune(dir)
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: keys list | generate [-alg EdDSA|RS256] | promote <kid> | prune | rotate [-alg EdDSA|RS256]")
+	os.Exit(2)
+}
+
+func generate(dir string, args []string) *token.KeyEntry {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	alg := flags.String("alg", "EdDSA", "signing algorithm: EdDSA or RS256")
+	flags.Parse(args)
+
+	entry, err := token.GenerateKey(dir, *alg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("generated", entry.Kid, entry.Alg)
+
+	return entry
+}
+
+func prune(dir string) {
+	pruned, err := token.PruneKeys(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, kid := range pruned {
+		fmt.Println("pruned", kid)
+	}
+}
+
+func list(dir string) {
+	manifest, err := token.ReadManifest(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KID\tALG\tSTATUS\tCREATED\tRETIRED")
+	for _, entry := range manifest.Keys {
+		retired := "-"
+		if entry.RetiredAt != nil {
+			retired = entry.RetiredAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.Kid, entry.Alg, entry.Status, entry.CreatedAt.Format(time.RFC3339), retired)
+	}
+	w.Flush()
+}
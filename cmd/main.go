@@ -6,8 +6,12 @@ import (
 	"auth-service/cmd/server"
 	"auth-service/config"
 	"auth-service/logs"
+	"auth-service/pkg/mailer"
+	"auth-service/ratelimit"
+	"auth-service/service"
 	"auth-service/storage/postgres"
 	"auth-service/storage/redis"
+	"context"
 	"log"
 	"log/slog"
 	"sync"
@@ -27,7 +31,38 @@ func main() {
 	redisClient := redis.NewRedisClient()
 
 	cfg := config.Load()
-	handle := handler.NewHandler(postgres.NewUserRepo(db), logs.Logger, redisClient)
+	mail, err := mailer.New(cfg, logs.Logger)
+	if err != nil {
+		logs.Logger.Error("Error creating mailer", slog.String("error", err.Error()))
+		log.Fatal(err)
+	}
+
+	// Outbox'dagi xatlarni fonda yuboradi
+	outbox := service.OutboxWorker{
+		Store:       postgres.NewUserRepo(db),
+		Mailer:      mail,
+		Logger:      logs.Logger,
+		MaxAttempts: cfg.OUTBOX_MAX_ATTEMPTS,
+		Interval:    cfg.OUTBOX_POLL_INTERVAL,
+		Retention:   cfg.OUTBOX_RETENTION,
+	}
+	go outbox.Run(context.Background())
+
+	// RATE_LIMIT_ENABLED=false bo'lsa limiter nil qoladi
+	var limiter *ratelimit.Limiter
+	if cfg.RATE_LIMIT_ENABLED {
+		limiter, err = ratelimit.NewLimiter(redisClient, ratelimit.HTTPLimits, cfg.RATE_LIMITS)
+		if err != nil {
+			logs.Logger.Error("Error reading rate limits", slog.String("error", err.Error()))
+			log.Fatal(err)
+		}
+	}
+
+	handle, err := handler.NewHandler(cfg, postgres.NewUserRepo(db), logs.Logger, redisClient, limiter)
+	if err != nil {
+		logs.Logger.Error("Error creating handler", slog.String("error", err.Error()))
+		log.Fatal(err)
+	}
 	router := api.NewRouter(handle)
 
 	var wg sync.WaitGroup
@@ -0,0 +1,55 @@
+// Command reconcile recomputes the follower and following counts of every
+// user from the followers table and reports the users whose stored counts
+// drifted.
+//
+//	go run ./cmd/reconcile        # report only
+//	go run ./cmd/reconcile -fix   # report and correct the counts
+//
+// Without -fix it exits with status 1 when it finds drift, so it can run as a
+// scheduled check.
+package main
+
+import (
+	"auth-service/storage/postgres"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+)
+
+func main() {
+	fix := flag.Bool("fix", false, "correct the stored counts")
+	flag.Parse()
+
+	db, err := postgres.ConnectDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	drift, err := postgres.NewUserRepo(db).ReconcileFollowCounts(*fix)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(drift) == 0 {
+		fmt.Println("follow counts are in sync")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "USER\tUSERNAME\tFOLLOWERS\tACTUAL\tFOLLOWING\tACTUAL")
+	for _, d := range drift {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\n", d.UserID, d.Username, d.FollowersCount, d.ActualFollowers,
+			d.FollowingCount, d.ActualFollowing)
+	}
+	w.Flush()
+
+	if *fix {
+		fmt.Printf("fixed %d users\n", len(drift))
+		return
+	}
+	fmt.Printf("%d users drifted, run with -fix to correct them\n", len(drift))
+	os.Exit(1)
+}
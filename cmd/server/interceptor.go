@@ -0,0 +1,153 @@
+package server
+
+import (
+	"auth-service/auth"
+	"auth-service/logs"
+	"auth-service/ratelimit"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// authenticate verifies the access token of the "authorization" metadata and
+// returns a context carrying the principal.
+func authenticate(ctx context.Context, verifier *auth.Verifier) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 || values[0] == "" {
+		return nil, status.Error(codes.Unauthenticated, "authorization token is required")
+	}
+
+	tokenString := auth.TokenFromHeader(values[0])
+	claims, err := verifier.Verify(tokenString)
+	if errors.Is(err, auth.ErrInvalidToken) {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if err != nil {
+		logs.Logger.Error("Error verifying token", slog.String("error", err.Error()))
+		return nil, status.Error(codes.Internal, "failed to verify token")
+	}
+
+	return auth.NewContext(ctx, auth.NewPrincipal(claims, tokenString)), nil
+}
+
+// authorize authenticates the caller unless the method is public and checks
+// the method's rule in auth.GRPCPolicy.
+func authorize(ctx context.Context, verifier *auth.Verifier, fullMethod string, req interface{}) (context.Context, error) {
+	rule, ok := auth.GRPCPolicy[fullMethod]
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "method is not allowed")
+	}
+	if rule.Public {
+		return ctx, nil
+	}
+
+	ctx, err := authenticate(ctx, verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, _ := auth.FromContext(ctx)
+	if err := rule.Allow(principal, req); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	return ctx, nil
+}
+
+func UnaryAuthInterceptor(verifier *auth.Verifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authorize(ctx, verifier, info.FullMethod, req)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authenticatedStream overrides the context of a server stream.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamAuthInterceptor checks streaming methods when the stream opens, before
+// any message is received, so Owner rules only let admins open a stream.
+func StreamAuthInterceptor(verifier *auth.Verifier) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authorize(ss.Context(), verifier, info.FullMethod, nil)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// rateLimit applies the limit of the method, per caller when the method is
+// authenticated and per peer address otherwise. Calls pass if Redis cannot be
+// reached.
+func rateLimit(ctx context.Context, limiter *ratelimit.Limiter, fullMethod string) error {
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+
+	principal, _ := auth.FromContext(ctx)
+	decision, err := limiter.Take(fullMethod, principal, ip)
+	if err != nil {
+		logs.Logger.Error("Error checking rate limit", slog.String("error", err.Error()))
+		return nil
+	}
+	if decision == nil {
+		return nil
+	}
+
+	md := metadata.MD{}
+	for name, value := range decision.Headers() {
+		md.Set(name, value)
+	}
+	if err := grpc.SetHeader(ctx, md); err != nil {
+		logs.Logger.Error("Error setting rate limit headers", slog.String("error", err.Error()))
+	}
+
+	if !decision.Allowed {
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry in %s", decision.RetryAfter.Round(time.Second))
+	}
+	return nil
+}
+
+// UnaryRateLimitInterceptor has to be chained after UnaryAuthInterceptor.
+func UnaryRateLimitInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := rateLimit(ctx, limiter, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamRateLimitInterceptor counts the opening of a stream as one call. It
+// has to be chained after StreamAuthInterceptor.
+func StreamRateLimitInterceptor(limiter *ratelimit.Limiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := rateLimit(ss.Context(), limiter, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
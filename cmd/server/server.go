@@ -1,9 +1,11 @@
 package server
 
 import (
+	"auth-service/auth"
 	"auth-service/config"
 	"auth-service/generated/user"
 	"auth-service/logs"
+	"auth-service/ratelimit"
 	"auth-service/service"
 	"auth-service/storage/postgres"
 	"auth-service/storage/redis"
@@ -22,11 +24,30 @@ func RunServer(userRepo *postgres.UserRepo, redisClient *redis.RedisClient) {
 		log.Fatal(err)
 	}
 
-	s := grpc.NewServer()
+	verifier := auth.NewVerifier(userRepo, redisClient)
+
+	limiter, err := ratelimit.NewLimiter(redisClient, ratelimit.GRPCLimits, cfg.RATE_LIMITS)
+	if err != nil {
+		logs.Logger.Error("Error reading rate limits", "error", err.Error())
+		log.Fatal(err)
+	}
+
+	unary := []grpc.UnaryServerInterceptor{UnaryAuthInterceptor(verifier)}
+	stream := []grpc.StreamServerInterceptor{StreamAuthInterceptor(verifier)}
+	if cfg.RATE_LIMIT_ENABLED {
+		unary = append(unary, UnaryRateLimitInterceptor(limiter))
+		stream = append(stream, StreamRateLimitInterceptor(limiter))
+	}
+
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
 	srv := service.UserService{
 		UserRepo: userRepo,
 		RedisClient: redisClient,
 		Logger: logs.Logger,
+		Verifier: verifier,
 	}
 
 	user.RegisterAuthServiceServer(s, &srv)
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/cast"
@@ -17,7 +18,51 @@ type Config struct {
 	DB_NAME       string
 	DB_PASSWORD   string
 	REFRESH_TOKEN string
-	ACCESS_TOKEN  string
+
+	JWT_KEYS_DIR string
+	ISSUER_URL   string
+
+	TOTP_ISSUER         string
+	TOTP_ENCRYPTION_KEY string
+
+	REQUIRE_VERIFIED_EMAIL bool
+	VERIFY_RESEND_LIMIT    int
+
+	PUBLIC_BASE_URL     string
+	MAIL_DEFAULT_LOCALE string
+
+	MAIL_BACKEND  string
+	MAIL_FROM     string
+	MAIL_DIR      string
+	SMTP_HOST     string
+	SMTP_PORT     string
+	SMTP_USERNAME string
+	SMTP_PASSWORD string
+
+	OUTBOX_MAX_ATTEMPTS  int
+	OUTBOX_POLL_INTERVAL time.Duration
+	OUTBOX_RETENTION     time.Duration
+
+	LOGIN_FREE_ATTEMPTS        int
+	LOGIN_MAX_ACCOUNT_FAILURES int
+	LOGIN_MAX_IP_FAILURES      int
+	LOGIN_FAILURE_WINDOW       time.Duration
+	LOGIN_MAX_DELAY            time.Duration
+	LOGIN_LOCKOUT              time.Duration
+
+	RATE_LIMIT_ENABLED bool
+	RATE_LIMITS        string
+
+	ARGON2_MEMORY      int
+	ARGON2_ITERATIONS  int
+	ARGON2_PARALLELISM int
+
+	PASSWORD_MIN_LENGTH   int
+	PASSWORD_MAX_LENGTH   int
+	PASSWORD_MIN_CLASSES  int
+	PASSWORD_BREACHED_DIR string
+
+	SUGGESTIONS_CACHE_TTL time.Duration
 }
 
 func Load() Config {
@@ -34,9 +79,54 @@ func Load() Config {
 	config.DB_USER = cast.ToString(coalesce("DB_USER", "postgres"))
 	config.DB_NAME = cast.ToString(coalesce("DB_NAME", "postgres"))
 	config.DB_PASSWORD = cast.ToString(coalesce("DB_PASSWORD", "passwrod"))
-	config.ACCESS_TOKEN = cast.ToString(coalesce("ACCESS_TOKEN", "my_secret_key"))
 	config.REFRESH_TOKEN = cast.ToString(coalesce("REFRESH_TOKEN", "my_secret_key"))
 
+	config.JWT_KEYS_DIR = cast.ToString(coalesce("JWT_KEYS_DIR", "keys"))
+	config.ISSUER_URL = cast.ToString(coalesce("ISSUER_URL", "http://localhost:8080"))
+
+	config.TOTP_ISSUER = cast.ToString(coalesce("TOTP_ISSUER", "Travel Tales"))
+	config.TOTP_ENCRYPTION_KEY = cast.ToString(coalesce("TOTP_ENCRYPTION_KEY", ""))
+
+	config.REQUIRE_VERIFIED_EMAIL = cast.ToBool(coalesce("REQUIRE_VERIFIED_EMAIL", false))
+	config.VERIFY_RESEND_LIMIT = cast.ToInt(coalesce("VERIFY_RESEND_LIMIT", 3))
+
+	config.PUBLIC_BASE_URL = cast.ToString(coalesce("PUBLIC_BASE_URL", "http://localhost:8080"))
+	config.MAIL_DEFAULT_LOCALE = cast.ToString(coalesce("MAIL_DEFAULT_LOCALE", "uz"))
+
+	config.MAIL_BACKEND = cast.ToString(coalesce("MAIL_BACKEND", "smtp"))
+	config.MAIL_FROM = cast.ToString(coalesce("MAIL_FROM", "Travel Tales <no-reply@traveltales.uz>"))
+	config.MAIL_DIR = cast.ToString(coalesce("MAIL_DIR", "mail"))
+	config.SMTP_HOST = cast.ToString(coalesce("SMTP_HOST", "localhost"))
+	config.SMTP_PORT = cast.ToString(coalesce("SMTP_PORT", "587"))
+	config.SMTP_USERNAME = cast.ToString(coalesce("SMTP_USERNAME", ""))
+	config.SMTP_PASSWORD = cast.ToString(coalesce("SMTP_PASSWORD", ""))
+
+	config.OUTBOX_MAX_ATTEMPTS = cast.ToInt(coalesce("OUTBOX_MAX_ATTEMPTS", 10))
+	config.OUTBOX_POLL_INTERVAL = cast.ToDuration(coalesce("OUTBOX_POLL_INTERVAL", "5s"))
+	config.OUTBOX_RETENTION = cast.ToDuration(coalesce("OUTBOX_RETENTION", "168h"))
+
+	config.LOGIN_FREE_ATTEMPTS = cast.ToInt(coalesce("LOGIN_FREE_ATTEMPTS", 3))
+	config.LOGIN_MAX_ACCOUNT_FAILURES = cast.ToInt(coalesce("LOGIN_MAX_ACCOUNT_FAILURES", 10))
+	config.LOGIN_MAX_IP_FAILURES = cast.ToInt(coalesce("LOGIN_MAX_IP_FAILURES", 100))
+	config.LOGIN_FAILURE_WINDOW = cast.ToDuration(coalesce("LOGIN_FAILURE_WINDOW", "15m"))
+	config.LOGIN_MAX_DELAY = cast.ToDuration(coalesce("LOGIN_MAX_DELAY", "30s"))
+	config.LOGIN_LOCKOUT = cast.ToDuration(coalesce("LOGIN_LOCKOUT", "30m"))
+
+	config.RATE_LIMIT_ENABLED = cast.ToBool(coalesce("RATE_LIMIT_ENABLED", true))
+	config.RATE_LIMITS = cast.ToString(coalesce("RATE_LIMITS", ""))
+
+	// Argon2id xotirasi KiB da
+	config.ARGON2_MEMORY = cast.ToInt(coalesce("ARGON2_MEMORY", 64*1024))
+	config.ARGON2_ITERATIONS = cast.ToInt(coalesce("ARGON2_ITERATIONS", 3))
+	config.ARGON2_PARALLELISM = cast.ToInt(coalesce("ARGON2_PARALLELISM", 2))
+
+	config.PASSWORD_MIN_LENGTH = cast.ToInt(coalesce("PASSWORD_MIN_LENGTH", 8))
+	config.PASSWORD_MAX_LENGTH = cast.ToInt(coalesce("PASSWORD_MAX_LENGTH", 128))
+	config.PASSWORD_MIN_CLASSES = cast.ToInt(coalesce("PASSWORD_MIN_CLASSES", 3))
+	config.PASSWORD_BREACHED_DIR = cast.ToString(coalesce("PASSWORD_BREACHED_DIR", ""))
+
+	config.SUGGESTIONS_CACHE_TTL = cast.ToDuration(coalesce("SUGGESTIONS_CACHE_TTL", "15m"))
+
 	return config
 }
 
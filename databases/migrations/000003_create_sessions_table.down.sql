@@ -0,0 +1,10 @@
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens RENAME COLUMN token_hash TO token;
+
+ALTER TABLE refresh_tokens
+    DROP CONSTRAINT IF EXISTS refresh_tokens_family_id_fkey,
+    ALTER COLUMN family_id SET DEFAULT GEN_RANDOM_UUID(),
+    ADD COLUMN username VARCHAR(255) NOT NULL;
+
+DROP TABLE IF EXISTS sessions;
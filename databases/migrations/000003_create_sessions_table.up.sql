@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID DEFAULT GEN_RANDOM_UUID() PRIMARY KEY,
+    user_id UUID NOT NULL,
+    user_agent TEXT,
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id) WHERE revoked_at IS NULL;
+
+-- Every session is a refresh token family: its refresh tokens keep rotating in
+-- refresh_tokens with family_id set to the session id, and only their SHA-256
+-- hashes are stored. Tokens issued before sessions belong to no session, so
+-- they are removed and their users log in again.
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens RENAME COLUMN token TO token_hash;
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN username,
+    ALTER COLUMN family_id DROP DEFAULT,
+    ADD CONSTRAINT refresh_tokens_family_id_fkey FOREIGN KEY (family_id) REFERENCES sessions (id);
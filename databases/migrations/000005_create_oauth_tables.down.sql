@@ -0,0 +1,6 @@
+ALTER TABLE sessions
+    DROP COLUMN IF EXISTS client_id,
+    DROP COLUMN IF EXISTS scope;
+
+DROP TABLE IF EXISTS oauth_consents;
+DROP TABLE IF EXISTS oauth_clients;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS followers_following_id_idx;
+
+ALTER TABLE followers
+    DROP CONSTRAINT IF EXISTS followers_no_self_follow,
+    DROP CONSTRAINT IF EXISTS followers_follower_id_following_id_key;
@@ -0,0 +1,14 @@
+-- Keep the oldest of duplicate follows and drop self-follows
+DELETE FROM followers f
+USING followers older
+WHERE f.follower_id = older.follower_id
+    AND f.following_id = older.following_id
+    AND (f.followed_at, f.id) > (older.followed_at, older.id);
+
+DELETE FROM followers WHERE follower_id = following_id;
+
+ALTER TABLE followers
+    ADD CONSTRAINT followers_follower_id_following_id_key UNIQUE (follower_id, following_id),
+    ADD CONSTRAINT followers_no_self_follow CHECK (follower_id <> following_id);
+
+CREATE INDEX IF NOT EXISTS followers_following_id_idx ON followers (following_id, follower_id);
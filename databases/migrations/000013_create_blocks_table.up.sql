@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS blocks (
+    id UUID DEFAULT GEN_RANDOM_UUID() PRIMARY KEY,
+    blocker_id UUID NOT NULL,
+    blocked_id UUID NOT NULL,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('block', 'mute')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (blocker_id) REFERENCES users (id),
+    FOREIGN KEY (blocked_id) REFERENCES users (id),
+    UNIQUE (blocker_id, blocked_id, kind),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX IF NOT EXISTS blocks_blocked_id_idx ON blocks (blocked_id, kind);
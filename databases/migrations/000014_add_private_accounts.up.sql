@@ -0,0 +1,15 @@
+ALTER TABLE users
+    ADD COLUMN is_private BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS follow_requests (
+    id UUID DEFAULT GEN_RANDOM_UUID() PRIMARY KEY,
+    requester_id UUID NOT NULL,
+    target_id UUID NOT NULL,
+    requested_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (requester_id) REFERENCES users (id),
+    FOREIGN KEY (target_id) REFERENCES users (id),
+    UNIQUE (requester_id, target_id),
+    CHECK (requester_id <> target_id)
+);
+
+CREATE INDEX IF NOT EXISTS follow_requests_target_id_idx ON follow_requests (target_id, requested_at);
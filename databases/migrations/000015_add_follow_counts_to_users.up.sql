@@ -0,0 +1,17 @@
+ALTER TABLE users
+    ADD COLUMN followers_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN following_count INTEGER NOT NULL DEFAULT 0;
+
+-- Follows of deleted users are not counted
+UPDATE users u
+SET
+    followers_count = (
+        SELECT COUNT(*) FROM followers f
+        INNER JOIN users other ON other.id = f.follower_id
+        WHERE f.following_id = u.id AND other.deleted_at = 0
+    ),
+    following_count = (
+        SELECT COUNT(*) FROM followers f
+        INNER JOIN users other ON other.id = f.following_id
+        WHERE f.follower_id = u.id AND other.deleted_at = 0
+    );
@@ -0,0 +1,2 @@
+-- Cleared email bodies cannot be restored.
+SELECT 1;
@@ -0,0 +1 @@
+UPDATE email_outbox SET text_body = '', html_body = '' WHERE status = 'sent';
@@ -26,20 +26,263 @@ type LoginResponse struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Role     string `json:"role"`
+
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+	EmailVerified    bool `json:"email_verified"`
+}
+
+type ResendVerification struct {
+	Email string `json:"email" binding:"required"`
 }
 
 type ResetPassword struct {
 	Email string `json:"email"`
 }
 
-type UpdatePassword struct {
-	ID          string `json:"id"`
-	NewPassword string `json:"new_password"`
+type NewPassword struct {
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type ChangePassword struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// PasswordViolation is a rule of the password policy a new password breaks.
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a rejected password breaks.
+type PasswordPolicyError struct {
+	Message    string              `json:"message"`
+	Violations []PasswordViolation `json:"violations"`
 }
 
 type Token struct {
+	AccessToken   string `json:"access_token"`
+	RefreshToken  string `json:"refresh_token"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// MFAChallenge is returned by login instead of tokens when the user has
+// two-factor authentication on. The mfa_token is sent to /auth/login/mfa.
+type MFAChallenge struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+// MFALoginRequest finishes a login with either a TOTP code or a recovery code.
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type TOTPCode struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// RecoveryCodes are shown once when two-factor authentication is turned on.
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+type Session struct {
+	ID               string `json:"id"`
+	UserID           string `json:"user_id"`
+	UserAgent        string `json:"user_agent"`
+	IPAddress        string `json:"ip_address"`
+	CreatedAt        string `json:"created_at"`
+	LastUsedAt       string `json:"last_used_at"`
+	ExpiresAt        string `json:"expires_at"`
+	ClientID         string `json:"client_id,omitempty"`
+	Scope            string `json:"scope,omitempty"`
+	Current          bool   `json:"current"`
+	RefreshTokenHash string `json:"-"`
+	Revoked          bool   `json:"-"`
+}
+
+type Sessions struct {
+	Sessions []Session `json:"sessions"`
+}
+
+// IntrospectionResponse is the RFC 7662 token introspection response.
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	TokenType string `json:"token_type,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Email     string `json:"email,omitempty"`
+	SessionID string `json:"sid,omitempty"`
+	Role      string `json:"role,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+}
+
+// OAuthError is the error format of the OAuth 2.0 endpoints (RFC 6749, 5.2).
+type OAuthError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// OAuthClient is an application registered to use the OAuth endpoints.
+// Public clients (mobile and browser apps) have no secret.
+type OAuthClient struct {
+	ID           string   `json:"id"`
+	ClientID     string   `json:"client_id"`
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	GrantTypes   []string `json:"grant_types"`
+	Confidential bool     `json:"confidential"`
+	CreatedAt    string   `json:"created_at"`
+	SecretHash   string   `json:"-"`
+}
+
+// CreateOAuthClientRequest registers either an application that signs users
+// in (authorization_code and refresh_token grants, the default) or a service
+// client using only the client_credentials grant.
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	GrantTypes   []string `json:"grant_types"`
+	Confidential bool     `json:"confidential"`
+}
+
+// OAuthClientCredentials is returned once on registration, the secret is
+// stored only as a hash.
+type OAuthClientCredentials struct {
+	OAuthClient
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// AuthorizeRequest holds the parameters of the authorization endpoint
+// (RFC 6749, 4.1.1 and RFC 7636, 4.3).
+type AuthorizeRequest struct {
+	ResponseType        string `json:"response_type" form:"response_type"`
+	ClientID            string `json:"client_id" form:"client_id"`
+	RedirectURI         string `json:"redirect_uri" form:"redirect_uri"`
+	Scope               string `json:"scope" form:"scope"`
+	State               string `json:"state" form:"state"`
+	CodeChallenge       string `json:"code_challenge" form:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method" form:"code_challenge_method"`
+	Nonce               string `json:"nonce" form:"nonce"`
+	Approve             bool   `json:"approve" form:"approve"`
+}
+
+// AuthorizeResponse either asks the frontend to show a consent screen or
+// tells it where to send the browser next.
+type AuthorizeResponse struct {
+	ConsentRequired bool   `json:"consent_required"`
+	ClientID        string `json:"client_id,omitempty"`
+	ClientName      string `json:"client_name,omitempty"`
+	Scope           string `json:"scope,omitempty"`
+	RedirectTo      string `json:"redirect_to,omitempty"`
+}
+
+// AuthorizationCode is what an issued authorization code stands for. It is
+// kept in Redis until it is redeemed or expires.
+type AuthorizationCode struct {
+	ClientID      string `json:"client_id"`
+	UserID        string `json:"user_id"`
+	RedirectURI   string `json:"redirect_uri"`
+	Scope         string `json:"scope"`
+	CodeChallenge string `json:"code_challenge"`
+	Nonce         string `json:"nonce,omitempty"`
+}
+
+// OAuthToken is the token endpoint response (RFC 6749, 5.1).
+type OAuthToken struct {
 	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+}
+
+// OpenIDConfiguration is the OpenID Connect discovery document.
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JwksURI                           string   `json:"jwks_uri"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// UserInfo is the OpenID Connect userinfo response. Claims outside the
+// granted scopes are left out.
+type UserInfo struct {
+	Sub               string `json:"sub"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     *bool  `json:"email_verified,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Name              string `json:"name,omitempty"`
+}
+
+// Outbox email statuses. A pending email is delivered by the outbox worker;
+// after the last failed attempt it becomes dead until an admin retries it.
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxDead    = "dead"
+)
+
+// OutboxEmail is an email queued in the email_outbox table.
+type OutboxEmail struct {
+	ID            string `json:"id"`
+	Recipient     string `json:"recipient"`
+	Subject       string `json:"subject"`
+	TextBody      string `json:"-"`
+	HTMLBody      string `json:"-"`
+	Status        string `json:"status"`
+	Attempts      int    `json:"attempts"`
+	LastError     string `json:"last_error,omitempty"`
+	NextAttemptAt string `json:"next_attempt_at,omitempty"`
+	CreatedAt     string `json:"created_at"`
+	SentAt        string `json:"sent_at,omitempty"`
+}
+
+type OutboxEmails struct {
+	Emails []OutboxEmail `json:"emails"`
+}
+
+// Security events recorded in the security_events table.
+const (
+	EventAccountLocked      = "account_locked"
+	EventAccountUnlocked    = "account_unlocked"
+	EventIPLocked           = "ip_locked"
+	EventRefreshTokenReused = "refresh_token_reused"
+)
+
+// SecurityEvent is a record of something that happened to an account or an
+// IP address. UserID is empty when the event is not tied to a known user.
+type SecurityEvent struct {
+	UserID    string
+	Event     string
+	Email     string
+	IPAddress string
+	UserAgent string
 }
 
 type Errors struct {
@@ -49,3 +292,21 @@ type Errors struct {
 type Success struct {
 	Message string `json:"message"`
 }
+
+// Kinds of rows in the blocks table. A block cuts the users apart; a mute only
+// asks other services to hide the muted user's content from the muter.
+const (
+	BlockKindBlock = "block"
+	BlockKindMute  = "mute"
+)
+
+// FollowCountDrift is a user whose stored follow counts differ from the
+// follows in the followers table.
+type FollowCountDrift struct {
+	UserID          string
+	Username        string
+	FollowersCount  int
+	ActualFollowers int
+	FollowingCount  int
+	ActualFollowing int
+}
@@ -0,0 +1,22 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	sealed, err := Encrypt("key", "JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, sealed, "JBSWY3DPEHPK3PXP")
+
+	plain, err := Decrypt("key", sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", plain)
+
+	_, err = Decrypt("other key", sealed)
+	assert.Error(t, err)
+}
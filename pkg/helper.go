@@ -1,28 +1,23 @@
 package pkg
 
-import "net/smtp"
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
 
-func SendEmail(to string, subject string, body string) error {
-	from := "nurmuhammadmel@gmail.com"
-	password := "qxxq bjej yprc plkz"
-
-	smtpHost := "smtp.gmail.com"
-	smtpPort := "587"
-
-	msg := "From: " + from + "\n" +
-		"To: " + to + "\n" +
-		"Subject: " + subject + "\n\n" +
-		body
-
-	auth := smtp.PlainAuth("", from, password, smtpHost)
-	err := smtp.SendMail(smtpHost+":"+smtpPort, auth, from, []string{to}, []byte(msg))
-	if err != nil {
-		return err
+// GenerateRandomString returns n random bytes encoded as hex.
+func GenerateRandomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
-	return nil
+	return hex.EncodeToString(b), nil
 }
 
-// Create a reset link
-func CreateResetLink(baseURL string, token string) string {
-	return "localhost:8081/swagger/index.html#" + baseURL + "/new-password?token=" + token + ""
+// HashToken returns the hex encoded SHA-256 of a token, used to store
+// tokens in the database without keeping the token itself.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"auth-service/pkg"
+)
+
+// FileMailer writes every message as a file into the "new" folder of a
+// maildir, where mail clients and tests can read them.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	return &FileMailer{
+		Dir:  dir,
+		From: from,
+	}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	data, err := msg.Bytes(m.From)
+	if err != nil {
+		return err
+	}
+
+	unique, err := pkg.GenerateRandomString(8)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d.%s.travel-auth.eml", time.Now().UnixNano(), unique)
+
+	// Maildir qoidasi: avval tmp ga yoziladi, keyin new ga ko'chiriladi
+	tmp := filepath.Join(m.Dir, "tmp", name)
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(m.Dir, "new", name))
+}
+
+// Messages returns the paths of the delivered messages, oldest first.
+func (m *FileMailer) Messages() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(m.Dir, "new", "*.eml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
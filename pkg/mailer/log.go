@@ -0,0 +1,27 @@
+package mailer
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogMailer does not deliver anything, it logs the recipient and subject of
+// the messages instead. The body is left out, because it carries reset and
+// verification links. It is meant for local development only.
+type LogMailer struct {
+	Logger *slog.Logger
+}
+
+func NewLogMailer(logger *slog.Logger) *LogMailer {
+	return &LogMailer{
+		Logger: logger,
+	}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.Logger.Info("email",
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+	)
+	return nil
+}
@@ -0,0 +1,32 @@
+// Package mailer sends transactional emails. The backend is chosen with
+// MAIL_BACKEND: "smtp" (the default) delivers through an SMTP server, "file"
+// drops every message into a maildir and "log" only writes the recipient and
+// subject to the log. The last two need no network and are meant for local
+// development and tests.
+package mailer
+
+import (
+	"auth-service/config"
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the mailer configured in cfg.
+func New(cfg config.Config, logger *slog.Logger) (Mailer, error) {
+	switch cfg.MAIL_BACKEND {
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTP_HOST, cfg.SMTP_PORT, cfg.SMTP_USERNAME, cfg.SMTP_PASSWORD, cfg.MAIL_FROM), nil
+	case "file":
+		return NewFileMailer(cfg.MAIL_DIR, cfg.MAIL_FROM)
+	case "log":
+		logger.Warn("MAIL_BACKEND is log, emails are not delivered")
+		return NewLogMailer(logger), nil
+	default:
+		return nil, fmt.Errorf("unknown mail backend %q", cfg.MAIL_BACKEND)
+	}
+}
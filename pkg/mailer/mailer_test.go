@@ -0,0 +1,143 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileMailer(t *testing.T) {
+	m, err := NewFileMailer(t.TempDir(), "Travel Tales <no-reply@traveltales.uz>")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Send(context.Background(), Message{
+		To:      "diyorbek@example.com",
+		Subject: "Parolni tiklash",
+		Text:    "Click on the link to reset your password",
+		HTML:    "<p>Click on the link to reset your password</p>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := m.Messages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, paths, 1) {
+		return
+	}
+
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "diyorbek@example.com", parsed.Header.Get("To"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Parolni tiklash", subject)
+	assert.True(t, strings.HasPrefix(parsed.Header.Get("Content-Type"), "multipart/alternative"))
+	assert.Contains(t, string(data), "<p>Click on the link to reset your password</p>")
+}
+
+func TestMessageHeaderInjection(t *testing.T) {
+	for _, msg := range []Message{
+		{To: "diyorbek@example.com\r\nBcc: victim@example.com", Subject: "Verify your email"},
+		{To: "diyorbek@example.com", Subject: "Verify your email\nBcc: victim@example.com"},
+	} {
+		_, err := msg.Bytes("Travel Tales <no-reply@traveltales.uz>")
+		assert.ErrorIs(t, err, ErrInvalidHeader)
+	}
+}
+
+func TestLogMailer(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewLogMailer(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	err := m.Send(context.Background(), Message{To: "diyorbek@example.com", Subject: "Verify your email", Text: "link"})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `"to":"diyorbek@example.com"`)
+	assert.Contains(t, buf.String(), `"subject":"Verify your email"`)
+	assert.NotContains(t, buf.String(), "link")
+}
+
+// fakeSMTPServer accepts one message and sends the MAIL FROM line to the
+// returned channel.
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	mailFrom := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "EHLO"), strings.HasPrefix(line, "HELO"):
+				tp.PrintfLine("250 localhost")
+			case strings.HasPrefix(line, "MAIL FROM:"):
+				mailFrom <- line
+				tp.PrintfLine("250 OK")
+			case strings.HasPrefix(line, "DATA"):
+				tp.PrintfLine("354 go ahead")
+				if _, err := tp.ReadDotBytes(); err != nil {
+					return
+				}
+				tp.PrintfLine("250 OK")
+			case strings.HasPrefix(line, "QUIT"):
+				tp.PrintfLine("221 bye")
+				return
+			default:
+				tp.PrintfLine("250 OK")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), mailFrom
+}
+
+func TestSMTPMailerEnvelopeSender(t *testing.T) {
+	addr, mailFrom := fakeSMTPServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+	m := NewSMTPMailer(host, port, "", "", "Travel Tales <no-reply@traveltales.uz>")
+
+	err := m.Send(context.Background(), Message{To: "diyorbek@example.com", Subject: "Verify your email", Text: "link"})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(<-mailFrom, "MAIL FROM:<no-reply@traveltales.uz>"))
+}
+
+func TestSMTPMailerInvalidSender(t *testing.T) {
+	m := NewSMTPMailer("localhost", "25", "", "", "Travel Tales")
+
+	err := m.Send(context.Background(), Message{To: "diyorbek@example.com", Subject: "Verify your email", Text: "link"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,157 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Message types. Every type has a <name>.txt and a <name>.html template in
+// each locale directory under templates/.
+const (
+	VerifyEmail    = "verify_email"
+	ResetPassword  = "reset_password"
+	NewDeviceLogin = "new_device_login"
+	AccountDeleted = "account_deleted"
+	AccountLocked  = "account_locked"
+)
+
+// Locales are the languages templates exist for. DefaultLocale is used when
+// none of them matches the recipient.
+var Locales = []string{"uz", "ru", "en"}
+
+const DefaultLocale = "uz"
+
+//go:embed templates
+var templateFS embed.FS
+
+// Data fills the placeholders of a template. Fields a message type does not
+// use are left empty.
+type Data struct {
+	Locale   string
+	Username string
+	Link     string
+	Device   string
+	IP       string
+	Time     string
+	// Expires is how long the link in the message works. Templates print it
+	// with ExpiresIn.
+	Expires time.Duration
+}
+
+// ExpiresIn spells Expires out in the message locale, in whole hours when it
+// divides evenly and in minutes otherwise.
+func (d Data) ExpiresIn() string {
+	n, unit := int64(d.Expires/time.Minute), "minute"
+	if d.Expires >= time.Hour && d.Expires%time.Hour == 0 {
+		n, unit = int64(d.Expires/time.Hour), "hour"
+	}
+
+	switch d.Locale {
+	case "en":
+		if n == 1 {
+			return fmt.Sprintf("%d %s", n, unit)
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	case "ru":
+		forms := map[string][3]string{
+			"minute": {"минуту", "минуты", "минут"},
+			"hour":   {"час", "часа", "часов"},
+		}[unit]
+		return fmt.Sprintf("%d %s", n, forms[russianPlural(n)])
+	default:
+		if unit == "hour" {
+			return fmt.Sprintf("%d soat", n)
+		}
+		return fmt.Sprintf("%d daqiqa", n)
+	}
+}
+
+// russianPlural picks the noun form for n: 0 for 1, 21, ...; 1 for 2-4,
+// 22-24, ...; 2 for the rest.
+func russianPlural(n int64) int {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return 0
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Render builds the message of the given type in locale. The subject and the
+// plain-text body come from <name>.txt, the HTML body from <name>.html placed
+// in the shared layout. An unknown locale falls back to DefaultLocale.
+func Render(name, locale string, data Data) (Message, error) {
+	if !supported(locale) {
+		locale = DefaultLocale
+	}
+	data.Locale = locale
+
+	dir := "templates/" + locale + "/"
+
+	text, err := texttemplate.ParseFS(templateFS, dir+name+".txt")
+	if err != nil {
+		return Message{}, fmt.Errorf("parse %s text template: %w", name, err)
+	}
+
+	html, err := htmltemplate.ParseFS(templateFS, "templates/layout.html", dir+name+".html")
+	if err != nil {
+		return Message{}, fmt.Errorf("parse %s html template: %w", name, err)
+	}
+
+	var subject, textBody, htmlBody bytes.Buffer
+
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := text.ExecuteTemplate(&textBody, "text", data); err != nil {
+		return Message{}, err
+	}
+
+	page := struct {
+		Data
+		Subject string
+	}{data, strings.TrimSpace(subject.String())}
+
+	if err := html.ExecuteTemplate(&htmlBody, "layout", page); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Subject: page.Subject,
+		Text:    strings.TrimLeft(textBody.String(), "\n"),
+		HTML:    htmlBody.String(),
+	}, nil
+}
+
+// Locale picks the template locale for an Accept-Language header. The first
+// language with templates wins; fallback is used if there is none.
+func Locale(acceptLanguage, fallback string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		lang := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if supported(lang) {
+			return lang
+		}
+	}
+
+	if supported(fallback) {
+		return fallback
+	}
+	return DefaultLocale
+}
+
+func supported(locale string) bool {
+	for _, l := range Locales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package mailer
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderAllTemplates(t *testing.T) {
+	names := []string{VerifyEmail, ResetPassword, NewDeviceLogin, AccountDeleted, AccountLocked}
+
+	for _, locale := range Locales {
+		for _, name := range names {
+			msg, err := Render(name, locale, Data{
+				Username: "diyorbek",
+				Link:     "https://traveltales.uz/reset-password?token=abc&x=1",
+				Device:   "Firefox on Linux",
+				IP:       "203.0.113.7",
+				Time:     "2024-08-01 10:00:00",
+				Expires:  30 * time.Minute,
+			})
+			if !assert.NoError(t, err, "%s/%s", locale, name) {
+				continue
+			}
+
+			assert.NotEmpty(t, msg.Subject, "%s/%s", locale, name)
+			assert.NotContains(t, msg.Subject, "\n")
+			assert.Contains(t, msg.Text, "diyorbek")
+			assert.Contains(t, msg.HTML, `lang="`+locale+`"`)
+			assert.Contains(t, msg.HTML, "<title>"+htmltemplate.HTMLEscapeString(msg.Subject)+"</title>")
+
+			if name != AccountDeleted {
+				assert.Contains(t, msg.Text, "https://traveltales.uz/reset-password?token=abc&x=1")
+				assert.Contains(t, msg.HTML, `href="https://traveltales.uz/reset-password?token=abc&amp;x=1"`)
+			}
+		}
+	}
+}
+
+func TestExpiresIn(t *testing.T) {
+	cases := []struct {
+		locale  string
+		expires time.Duration
+		want    string
+	}{
+		{"en", 30 * time.Minute, "30 minutes"},
+		{"en", time.Minute, "1 minute"},
+		{"en", 24 * time.Hour, "24 hours"},
+		{"en", 90 * time.Minute, "90 minutes"},
+		{"ru", 30 * time.Minute, "30 минут"},
+		{"ru", 21 * time.Minute, "21 минуту"},
+		{"ru", 24 * time.Hour, "24 часа"},
+		{"ru", 12 * time.Hour, "12 часов"},
+		{"uz", 30 * time.Minute, "30 daqiqa"},
+		{"uz", 24 * time.Hour, "24 soat"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.want, Data{Locale: c.locale, Expires: c.expires}.ExpiresIn(), "%s %s", c.locale, c.expires)
+	}
+}
+
+func TestRenderExpiry(t *testing.T) {
+	msg, err := Render(ResetPassword, "en", Data{Link: "https://traveltales.uz", Expires: 15 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, msg.Text, "valid for 15 minutes")
+	assert.Contains(t, msg.HTML, "valid for 15 minutes")
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	msg, err := Render(VerifyEmail, "en", Data{Username: "<script>alert(1)</script>", Link: "javascript:alert(1)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, strings.Contains(msg.HTML, "<script>"))
+	assert.NotContains(t, msg.HTML, `href="javascript:`)
+}
+
+func TestRenderUnknownLocale(t *testing.T) {
+	msg, err := Render(VerifyEmail, "de", Data{Link: "https://traveltales.uz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "Emailingizni tasdiqlang", msg.Subject)
+}
+
+func TestLocale(t *testing.T) {
+	assert.Equal(t, "ru", Locale("ru-RU,ru;q=0.9,en;q=0.8", "uz"))
+	assert.Equal(t, "en", Locale("de-DE, en-US;q=0.7", "uz"))
+	assert.Equal(t, "uz", Locale("", "uz"))
+	assert.Equal(t, "en", Locale("fr", "en"))
+	assert.Equal(t, DefaultLocale, Locale("fr", "xx"))
+}
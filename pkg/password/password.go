@@ -0,0 +1,174 @@
+// Package password hashes and verifies user passwords. Hashes are stored in
+// the PHC string format,
+//
+//	$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+//
+// with the salt and hash in unpadded base64. Hashes made by bcrypt before
+// Argon2id was introduced still verify and are reported by NeedsRehash.
+package password
+
+import (
+	"auth-service/config"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownFormat is returned for stored hashes no hasher understands.
+var ErrUnknownFormat = errors.New("unknown password hash format")
+
+type Hasher interface {
+	// Hash returns the encoded hash of a new password.
+	Hash(password string) (string, error)
+	// Verify reports whether password matches the encoded hash. An error
+	// means the hash itself could not be read.
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash reports whether encoded was made with another algorithm
+	// or other parameters than Hash would use now.
+	NeedsRehash(encoded string) bool
+}
+
+// Params are the Argon2id cost parameters. Memory is in KiB.
+type Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultParams follow the second recommended option of RFC 9106 with the
+// memory lowered to 64 MiB.
+var DefaultParams = Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// ParamsFromConfig returns DefaultParams with the costs set in cfg. Costs
+// argon2 cannot use are an error, so they are caught at startup instead of
+// at the first login.
+func ParamsFromConfig(cfg config.Config) (Params, error) {
+	switch {
+	case cfg.ARGON2_PARALLELISM < 1 || cfg.ARGON2_PARALLELISM > 255:
+		return Params{}, fmt.Errorf("ARGON2_PARALLELISM must be between 1 and 255, got %d", cfg.ARGON2_PARALLELISM)
+	case cfg.ARGON2_ITERATIONS < 1 || int64(cfg.ARGON2_ITERATIONS) > math.MaxUint32:
+		return Params{}, fmt.Errorf("ARGON2_ITERATIONS must be between 1 and %d, got %d", uint32(math.MaxUint32), cfg.ARGON2_ITERATIONS)
+	case cfg.ARGON2_MEMORY < 8*cfg.ARGON2_PARALLELISM || int64(cfg.ARGON2_MEMORY) > math.MaxUint32:
+		return Params{}, fmt.Errorf("ARGON2_MEMORY must be between 8 KiB per ARGON2_PARALLELISM (%d) and %d, got %d",
+			8*cfg.ARGON2_PARALLELISM, uint32(math.MaxUint32), cfg.ARGON2_MEMORY)
+	}
+
+	params := DefaultParams
+	params.Memory = uint32(cfg.ARGON2_MEMORY)
+	params.Iterations = uint32(cfg.ARGON2_ITERATIONS)
+	params.Parallelism = uint8(cfg.ARGON2_PARALLELISM)
+	return params, nil
+}
+
+type Argon2idHasher struct {
+	Params Params
+}
+
+func NewArgon2idHasher(params Params) *Argon2idHasher {
+	return &Argon2idHasher{Params: params}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.Params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Params.Iterations, h.Params.Memory, h.Params.Parallelism, h.Params.KeyLength)
+
+	return encode(h.Params, salt, key), nil
+}
+
+func (h *Argon2idHasher) Verify(password, encoded string) (bool, error) {
+	if isBcrypt(encoded) {
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	params, salt, key, err := decode(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, salt, _, err := decode(encoded)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory != h.Params.Memory ||
+		params.Iterations != h.Params.Iterations ||
+		params.Parallelism != h.Params.Parallelism ||
+		params.KeyLength != h.Params.KeyLength ||
+		uint32(len(salt)) != h.Params.SaltLength
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func encode(params Params, salt, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, params.Memory, params.Iterations,
+		params.Parallelism, base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decode(encoded string) (Params, []byte, []byte, error) {
+	var params Params
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, ErrUnknownFormat
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil || params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, ErrUnknownFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownFormat
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownFormat
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,114 @@
+package password
+
+import (
+	"auth-service/config"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testParams keep the tests fast; production uses DefaultParams.
+var testParams = Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idRoundTrip(t *testing.T) {
+	h := NewArgon2idHasher(testParams)
+
+	encoded, err := h.Hash("sqwerty007")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	ok, err := h.Verify("sqwerty007", encoded)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = h.Verify("sqwerty008", encoded)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.False(t, h.NeedsRehash(encoded))
+
+	other, err := h.Hash("sqwerty007")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEqual(t, encoded, other, "salt must be random")
+}
+
+func TestVerifyBcrypt(t *testing.T) {
+	h := NewArgon2idHasher(testParams)
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("sqwerty007"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := h.Verify("sqwerty007", string(legacy))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = h.Verify("wrong", string(legacy))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.True(t, h.NeedsRehash(string(legacy)))
+}
+
+func TestNeedsRehashOnParamChange(t *testing.T) {
+	old := NewArgon2idHasher(testParams)
+
+	encoded, err := old.Hash("sqwerty007")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stronger := testParams
+	stronger.Iterations = 2
+	h := NewArgon2idHasher(stronger)
+
+	assert.True(t, h.NeedsRehash(encoded))
+
+	// Old hashes keep verifying with their own parameters
+	ok, err := h.Verify("sqwerty007", encoded)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	h := NewArgon2idHasher(testParams)
+
+	for _, encoded := range []string{
+		"",
+		"plaintext",
+		"$argon2i$v=19$m=1024,t=1,p=1$c2FsdHNhbHQ$aGFzaA",
+		"$argon2id$v=16$m=1024,t=1,p=1$c2FsdHNhbHQ$aGFzaA",
+		"$argon2id$v=19$m=0,t=1,p=1$c2FsdHNhbHQ$aGFzaA",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!$aGFzaA",
+	} {
+		ok, err := h.Verify("sqwerty007", encoded)
+		assert.Error(t, err, encoded)
+		assert.False(t, ok)
+		assert.True(t, h.NeedsRehash(encoded))
+	}
+}
+
+func TestParamsFromConfig(t *testing.T) {
+	cfg := config.Config{ARGON2_MEMORY: 64 * 1024, ARGON2_ITERATIONS: 3, ARGON2_PARALLELISM: 2}
+	params, err := ParamsFromConfig(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultParams, params)
+
+	for _, bad := range []config.Config{
+		{ARGON2_MEMORY: 64 * 1024, ARGON2_ITERATIONS: 3, ARGON2_PARALLELISM: 0},
+		{ARGON2_MEMORY: 64 * 1024, ARGON2_ITERATIONS: 3, ARGON2_PARALLELISM: 256},
+		{ARGON2_MEMORY: 64 * 1024, ARGON2_ITERATIONS: 0, ARGON2_PARALLELISM: 2},
+		{ARGON2_MEMORY: 8, ARGON2_ITERATIONS: 3, ARGON2_PARALLELISM: 2},
+	} {
+		_, err := ParamsFromConfig(bad)
+		assert.Error(t, err)
+	}
+}
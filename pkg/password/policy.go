@@ -0,0 +1,186 @@
+package password
+
+import (
+	"auth-service/config"
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rules a password can break.
+const (
+	RuleMinLength        = "min_length"
+	RuleMaxLength        = "max_length"
+	RuleCharacterClasses = "character_classes"
+	RuleContainsUsername = "contains_username"
+	RuleContainsEmail    = "contains_email"
+	RuleBreached         = "breached"
+)
+
+// minIdentityLength keeps very short usernames and email names from ruling
+// out ordinary passwords.
+const minIdentityLength = 3
+
+// Violation is a rule a password breaks.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Policy says what a new password has to look like. Zero values turn a rule
+// off.
+type Policy struct {
+	MinLength int
+	MaxLength int
+	// MinClasses is how many of lowercase letters, uppercase letters, digits
+	// and other characters the password has to mix.
+	MinClasses int
+	// Breached is checked last, so the corpus is not read for passwords the
+	// other rules already reject.
+	Breached *BreachedPasswords
+}
+
+// PolicyFromConfig returns the policy set in cfg.
+func PolicyFromConfig(cfg config.Config) Policy {
+	policy := Policy{
+		MinLength:  cfg.PASSWORD_MIN_LENGTH,
+		MaxLength:  cfg.PASSWORD_MAX_LENGTH,
+		MinClasses: cfg.PASSWORD_MIN_CLASSES,
+	}
+	if cfg.PASSWORD_BREACHED_DIR != "" {
+		policy.Breached = &BreachedPasswords{Dir: cfg.PASSWORD_BREACHED_DIR}
+	}
+	return policy
+}
+
+// Check returns the rules password breaks for the user with username and
+// email. The error reports a failure to read the breached password corpus;
+// the other rules are checked anyway.
+func (p Policy) Check(password, username, email string) ([]Violation, error) {
+	violations := []Violation{}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMinLength,
+			Message: fmt.Sprintf("password must be at least %d characters long", p.MinLength),
+		})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMaxLength,
+			Message: fmt.Sprintf("password must be at most %d characters long", p.MaxLength),
+		})
+	}
+
+	if classes := characterClasses(password); classes < p.MinClasses {
+		violations = append(violations, Violation{
+			Rule: RuleCharacterClasses,
+			Message: fmt.Sprintf("password must mix at least %d of lowercase letters, uppercase letters, digits and symbols",
+				p.MinClasses),
+		})
+	}
+
+	lower := strings.ToLower(password)
+	if name := strings.ToLower(username); len(name) >= minIdentityLength && strings.Contains(lower, name) {
+		violations = append(violations, Violation{
+			Rule:    RuleContainsUsername,
+			Message: "password must not contain the username",
+		})
+	}
+	if name := strings.ToLower(strings.SplitN(email, "@", 2)[0]); len(name) >= minIdentityLength && strings.Contains(lower, name) {
+		violations = append(violations, Violation{
+			Rule:    RuleContainsEmail,
+			Message: "password must not contain the email address",
+		})
+	}
+
+	if p.Breached == nil || len(violations) > 0 {
+		return violations, nil
+	}
+
+	breached, err := p.Breached.Contains(password)
+	if err != nil {
+		return violations, err
+	}
+	if breached {
+		violations = append(violations, Violation{
+			Rule:    RuleBreached,
+			Message: "password has appeared in a data breach, choose another one",
+		})
+	}
+
+	return violations, nil
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	classes := 0
+	for _, has := range []bool{lower, upper, digit, other} {
+		if has {
+			classes++
+		}
+	}
+	return classes
+}
+
+// BreachedPasswords is an offline copy of a breached password corpus in the
+// layout of the Have I Been Pwned range API: Dir holds one file per first
+// five hex digits of the SHA-1 of a password, named after them (e.g.
+// "5BAA6" or "5BAA6.txt"), with one "SUFFIX:COUNT" line for every hash
+// starting with them.
+type BreachedPasswords struct {
+	Dir string
+}
+
+// Contains reports whether the password is in the corpus. A missing range
+// file means no password with that prefix was breached.
+func (b *BreachedPasswords) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(filepath.Join(b.Dir, prefix))
+	if errors.Is(err, os.ErrNotExist) {
+		file, err = os.Open(filepath.Join(b.Dir, prefix+".txt"))
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		candidate, count, _ := strings.Cut(line, ":")
+		// Padding lines of the range API have a count of 0
+		if strings.EqualFold(candidate, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
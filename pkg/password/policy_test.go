@@ -0,0 +1,85 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rules(violations []Violation) []string {
+	names := []string{}
+	for _, v := range violations {
+		names = append(names, v.Rule)
+	}
+	return names
+}
+
+func TestPolicyCheck(t *testing.T) {
+	policy := Policy{MinLength: 8, MaxLength: 64, MinClasses: 3}
+
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"", []string{RuleMinLength, RuleCharacterClasses}},
+		{"Tr4vel!", []string{RuleMinLength}},
+		{"travelling", []string{RuleCharacterClasses}},
+		{"Diyorbek2007", []string{RuleContainsUsername}},
+		{"Xsanjar2007!", []string{RuleContainsEmail}},
+		{"Samarqand-2024", []string{}},
+		{"Самарқанд-2024", []string{}},
+	}
+
+	for _, tt := range tests {
+		violations, err := policy.Check(tt.password, "diyorbek", "sanjar@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, rules(violations), tt.password)
+	}
+}
+
+func TestPolicyShortIdentity(t *testing.T) {
+	policy := Policy{MinLength: 8}
+
+	// A two letter username would rule out too many passwords
+	violations, err := policy.Check("aliqwerty99", "al", "al@example.com")
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+func TestBreachedPasswords(t *testing.T) {
+	dir := t.TempDir()
+
+	// SHA-1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	err := os.WriteFile(filepath.Join(dir, "5BAA6"), []byte(
+		"003D68EB55068C33ACE09247EE4C639306B:3\r\n"+
+			"1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// SHA-1("Samarqand-2024") starts with 4B734; the padding line must not count
+	err = os.WriteFile(filepath.Join(dir, "4B734.txt"), []byte("CFADFBCB10FB9B978218260654D3FC0B4BF:0\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	breached := &BreachedPasswords{Dir: dir}
+
+	ok, err := breached.Contains("password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = breached.Contains("Samarqand-2024")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = breached.Contains("no range file for this one")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	policy := Policy{MinLength: 8, Breached: breached}
+	violations, err := policy.Check("password", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{RuleBreached}, rules(violations))
+}
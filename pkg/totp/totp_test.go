@@ -0,0 +1,55 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRFC6238Vectors(t *testing.T) {
+	// RFC 6238, Appendix B (SHA1), last six digits of the eight digit values
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		code, err := Code(secret, Step(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, want, code, "time %d", unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	code, err := Code(secret, Step(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, ok, err := Validate(secret, code, now)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	// Bir qadam oldin va keyin ham qabul qilinadi
+	_, ok, _ = Validate(secret, code, now.Add(Period*time.Second))
+	assert.True(t, ok)
+	_, ok, _ = Validate(secret, code, now.Add(-Period*time.Second))
+	assert.True(t, ok)
+
+	_, ok, _ = Validate(secret, code, now.Add(3*Period*time.Second))
+	assert.False(t, ok)
+	_, ok, _ = Validate(secret, "12345", now)
+	assert.False(t, ok)
+}
@@ -0,0 +1,167 @@
+// Package ratelimit limits requests per route or RPC with token buckets kept
+// in Redis, so a limit holds across every replica of the service.
+package ratelimit
+
+import (
+	"auth-service/auth"
+	"auth-service/storage/redis"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// By says whose requests share a bucket.
+type By string
+
+const (
+	// ByIP counts requests per client IP address.
+	ByIP By = "ip"
+	// ByUser counts requests per signed-in user. Service clients are counted
+	// per client and anonymous requests per IP address.
+	ByUser By = "user"
+	// ByClient counts requests per OAuth client, anonymous requests per IP
+	// address.
+	ByClient By = "client"
+)
+
+// Limit allows Requests per Per, in bursts of up to Requests.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	By       By
+}
+
+// Default is the key of the limit used for routes or methods without one.
+const Default = "*"
+
+// Decision is the outcome of Take for one request.
+type Decision struct {
+	Limit Limit
+	redis.RateLimitResult
+}
+
+type Limiter struct {
+	RedisClient *redis.RedisClient
+	Limits      map[string]Limit
+}
+
+// NewLimiter returns a limiter with the limits in defaults, replaced or
+// extended by overrides (see ParseLimits).
+func NewLimiter(redisClient *redis.RedisClient, defaults map[string]Limit, overrides string) (*Limiter, error) {
+	limits := make(map[string]Limit, len(defaults))
+	for name, limit := range defaults {
+		limits[name] = limit
+	}
+
+	parsed, err := ParseLimits(overrides)
+	if err != nil {
+		return nil, err
+	}
+	for name, limit := range parsed {
+		limits[name] = limit
+	}
+
+	return &Limiter{
+		RedisClient: redisClient,
+		Limits:      limits,
+	}, nil
+}
+
+// Take counts a request to name, a "METHOD /route/pattern" or a full gRPC
+// method. It returns nil if no limit applies.
+func (l *Limiter) Take(name string, principal *auth.Principal, ip string) (*Decision, error) {
+	limit, ok := l.Limits[name]
+	if !ok {
+		limit, ok = l.Limits[Default]
+	}
+	if !ok || limit.Requests <= 0 {
+		return nil, nil
+	}
+
+	res, err := l.RedisClient.RateLimit("ratelimit:"+name+":"+key(limit.By, principal, ip), limit.Requests, limit.Per)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Decision{Limit: limit, RateLimitResult: *res}, nil
+}
+
+// Headers returns the RateLimit-* response headers for the decision, plus
+// Retry-After when the request was rejected.
+func (d *Decision) Headers() map[string]string {
+	headers := map[string]string{
+		"RateLimit-Limit":     strconv.Itoa(d.Limit.Requests),
+		"RateLimit-Remaining": strconv.Itoa(d.Remaining),
+		"RateLimit-Reset":     strconv.Itoa(seconds(d.ResetAfter)),
+		"RateLimit-Policy":    fmt.Sprintf("%d;w=%d", d.Limit.Requests, seconds(d.Limit.Per)),
+	}
+	if !d.Allowed {
+		headers["Retry-After"] = strconv.Itoa(seconds(d.RetryAfter))
+	}
+	return headers
+}
+
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+func key(by By, principal *auth.Principal, ip string) string {
+	switch {
+	case by == ByUser && principal != nil && principal.UserID != "":
+		return "user:" + principal.UserID
+	case (by == ByUser || by == ByClient) && principal != nil && principal.ClientID != "":
+		return "client:" + principal.ClientID
+	default:
+		return "ip:" + ip
+	}
+}
+
+// ParseLimits parses limits written as "NAME=REQUESTS/PERIOD[/BY]" separated
+// by ";", for example
+//
+//	POST /api/v1/auth/register=5/1h;/auth_service.AuthService/ListUsers=100/1m/user
+//
+// BY is ip, user or client and defaults to ip. REQUESTS 0 turns the limit of
+// NAME off.
+func ParseLimits(s string) (map[string]Limit, error) {
+	limits := map[string]Limit{}
+
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		i := strings.LastIndex(entry, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("rate limit %q: missing \"=\"", entry)
+		}
+		name, spec := strings.TrimSpace(entry[:i]), strings.Split(entry[i+1:], "/")
+		if name == "" || len(spec) < 2 || len(spec) > 3 {
+			return nil, fmt.Errorf("rate limit %q: want NAME=REQUESTS/PERIOD[/BY]", entry)
+		}
+
+		requests, err := strconv.Atoi(spec[0])
+		if err != nil || requests < 0 {
+			return nil, fmt.Errorf("rate limit %q: invalid number of requests", entry)
+		}
+
+		per, err := time.ParseDuration(spec[1])
+		if err != nil || per <= 0 {
+			return nil, fmt.Errorf("rate limit %q: invalid period", entry)
+		}
+
+		by := ByIP
+		if len(spec) == 3 {
+			by = By(spec[2])
+			if by != ByIP && by != ByUser && by != ByClient {
+				return nil, fmt.Errorf("rate limit %q: key must be ip, user or client", entry)
+			}
+		}
+
+		limits[name] = Limit{Requests: requests, Per: per, By: by}
+	}
+
+	return limits, nil
+}
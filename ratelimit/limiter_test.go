@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"auth-service/auth"
+	"auth-service/storage/redis"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits(" POST /api/v1/auth/register=5/1h ; /auth_service.AuthService/ListUsers=100/1m/user;*=0/1s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[string]Limit{
+		"POST /api/v1/auth/register":          {Requests: 5, Per: time.Hour, By: ByIP},
+		"/auth_service.AuthService/ListUsers": {Requests: 100, Per: time.Minute, By: ByUser},
+		Default:                               {Requests: 0, Per: time.Second, By: ByIP},
+	}, limits)
+
+	for _, bad := range []string{"POST /x", "POST /x=5", "POST /x=a/1m", "POST /x=5/soon", "POST /x=5/1m/device", "=5/1m"} {
+		_, err := ParseLimits(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestKey(t *testing.T) {
+	user := &auth.Principal{UserID: "u1", ClientID: "app"}
+	service := &auth.Principal{ClientID: "reports"}
+
+	assert.Equal(t, "user:u1", key(ByUser, user, "203.0.113.7"))
+	assert.Equal(t, "client:reports", key(ByUser, service, "203.0.113.7"))
+	assert.Equal(t, "ip:203.0.113.7", key(ByUser, nil, "203.0.113.7"))
+	assert.Equal(t, "client:app", key(ByClient, user, "203.0.113.7"))
+	assert.Equal(t, "ip:203.0.113.7", key(ByClient, nil, "203.0.113.7"))
+	assert.Equal(t, "ip:203.0.113.7", key(ByIP, user, "203.0.113.7"))
+}
+
+func TestNewLimiterOverrides(t *testing.T) {
+	l, err := NewLimiter(nil, HTTPLimits, "POST /api/v1/auth/register=1/1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, Limit{Requests: 1, Per: time.Minute, By: ByIP}, l.Limits["POST /api/v1/auth/register"])
+	assert.Equal(t, HTTPLimits[Default], l.Limits[Default])
+	assert.Equal(t, 10, HTTPLimits["POST /api/v1/auth/register"].Requests, "defaults must not change")
+}
+
+func TestDecisionHeaders(t *testing.T) {
+	d := &Decision{
+		Limit: Limit{Requests: 5, Per: time.Hour},
+		RateLimitResult: redis.RateLimitResult{
+			Remaining:  0,
+			RetryAfter: 1500 * time.Millisecond,
+			ResetAfter: 59 * time.Minute,
+		},
+	}
+
+	assert.Equal(t, map[string]string{
+		"RateLimit-Limit":     "5",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "3540",
+		"RateLimit-Policy":    "5;w=3600",
+		"Retry-After":         "2",
+	}, d.Headers())
+}
@@ -0,0 +1,36 @@
+package ratelimit
+
+import "time"
+
+// HTTPLimits maps "METHOD /route/pattern" to its limit. Routes that create
+// accounts, send email or mint tokens get tight per-IP limits; the rest share
+// the Default limit.
+var HTTPLimits = map[string]Limit{
+	Default: {Requests: 300, Per: time.Minute, By: ByUser},
+
+	"POST /api/v1/auth/register":                    {Requests: 10, Per: time.Hour, By: ByIP},
+	"POST /api/v1/auth/login":                       {Requests: 30, Per: time.Minute, By: ByIP},
+	"POST /api/v1/auth/login/mfa":                   {Requests: 30, Per: time.Minute, By: ByIP},
+	"POST /api/v1/auth/refresh":                     {Requests: 30, Per: time.Minute, By: ByIP},
+	"POST /api/v1/auth/reset-password":              {Requests: 5, Per: time.Hour, By: ByIP},
+	"POST /api/v1/auth/reset-password/new-password": {Requests: 10, Per: time.Hour, By: ByIP},
+	"POST /api/v1/auth/change-password":             {Requests: 10, Per: time.Hour, By: ByUser},
+	"POST /api/v1/auth/verify-email/resend":         {Requests: 10, Per: time.Hour, By: ByIP},
+	"GET /api/v1/auth/verify-email":                 {Requests: 30, Per: time.Hour, By: ByIP},
+	"GET /api/v1/auth/unlock":                       {Requests: 30, Per: time.Hour, By: ByIP},
+	"POST /oauth/token":                             {Requests: 60, Per: time.Minute, By: ByIP},
+	"POST /oauth/introspect":                        {Requests: 600, Per: time.Minute, By: ByIP},
+}
+
+// GRPCLimits maps full AuthService method names to their limit.
+var GRPCLimits = map[string]Limit{
+	Default: {Requests: 600, Per: time.Minute, By: ByUser},
+
+	"/auth_service.AuthService/FollowUser":    {Requests: 60, Per: time.Minute, By: ByUser},
+	"/auth_service.AuthService/UnfollowUser":  {Requests: 60, Per: time.Minute, By: ByUser},
+	"/auth_service.AuthService/BlockUser":     {Requests: 30, Per: time.Minute, By: ByUser},
+	"/auth_service.AuthService/MuteUser":      {Requests: 30, Per: time.Minute, By: ByUser},
+	"/auth_service.AuthService/ListUsers":     {Requests: 120, Per: time.Minute, By: ByUser},
+	"/auth_service.AuthService/SuggestUsers":  {Requests: 30, Per: time.Minute, By: ByUser},
+	"/auth_service.AuthService/ValidateToken": {Requests: 3000, Per: time.Minute, By: ByClient},
+}
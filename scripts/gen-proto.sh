@@ -3,6 +3,14 @@ CURRENT_DIR=$1
 rm -rf ${CURRENT_DIR}/generated
 
 for x in $(find ${CURRENT_DIR}/travel_tales-grpc-proto/* -type d); do
+  # AuthService is defined in protos/ of this repo
+  if [ -d ${CURRENT_DIR}/protos/$(basename ${x}) ]; then
+    continue
+  fi
   protoc -I=${x} -I=${CURRENT_DIR}/travel_tales-grpc-proto/ -I /usr/local/go --go_out=${CURRENT_DIR} \
    --go-grpc_out=${CURRENT_DIR} ${x}/*.proto
-done
\ No newline at end of file
+done
+
+for x in $(find ${CURRENT_DIR}/protos/* -type d); do
+  protoc -I=${x} --go_out=${CURRENT_DIR} --go-grpc_out=${CURRENT_DIR} ${x}/*.proto
+done
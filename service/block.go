@@ -0,0 +1,80 @@
+package service
+
+import (
+	pb "auth-service/generated/user"
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *UserService) BlockUser(ctx context.Context, in *pb.BlockUserRequest) (*pb.BlockUserResponse, error) {
+	if in.BlockerId == in.BlockedId {
+		return nil, status.Error(codes.InvalidArgument, "users cannot block themselves")
+	}
+
+	resp, err := s.UserRepo.BlockUser(in)
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if err != nil {
+		s.Logger.Error("Error block user", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	s.forgetSuggestions(in.BlockerId, in.BlockedId)
+	return resp, nil
+}
+
+func (s *UserService) UnblockUser(ctx context.Context, in *pb.UnblockUserRequest) (*pb.UnblockUserResponse, error) {
+	resp, err := s.UserRepo.UnblockUser(in)
+	if err != nil {
+		s.Logger.Error("Error unblock user", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	s.forgetSuggestions(in.BlockerId, in.BlockedId)
+	return resp, nil
+}
+
+func (s *UserService) MuteUser(ctx context.Context, in *pb.MuteUserRequest) (*pb.MuteUserResponse, error) {
+	if in.MuterId == in.MutedId {
+		return nil, status.Error(codes.InvalidArgument, "users cannot mute themselves")
+	}
+
+	resp, err := s.UserRepo.MuteUser(in)
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if err != nil {
+		s.Logger.Error("Error mute user", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	s.forgetSuggestions(in.MuterId)
+	return resp, nil
+}
+
+func (s *UserService) UnmuteUser(ctx context.Context, in *pb.UnmuteUserRequest) (*pb.UnmuteUserResponse, error) {
+	resp, err := s.UserRepo.UnmuteUser(in)
+	if err != nil {
+		s.Logger.Error("Error unmute user", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	s.forgetSuggestions(in.MuterId)
+	return resp, nil
+}
+
+// IsBlocked is called by the communication and stories services before they
+// deliver a message or a comment from user_id to other_id.
+func (s *UserService) IsBlocked(ctx context.Context, in *pb.IsBlockedRequest) (*pb.IsBlockedResponse, error) {
+	resp, err := s.UserRepo.IsBlocked(in)
+	if err != nil {
+		s.Logger.Error("Error check block", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return resp, nil
+}
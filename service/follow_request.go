@@ -0,0 +1,60 @@
+package service
+
+import (
+	pb "auth-service/generated/user"
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *UserService) SetAccountPrivacy(ctx context.Context, in *pb.SetAccountPrivacyRequest) (*pb.SetAccountPrivacyResponse, error) {
+	resp, approved, err := s.UserRepo.SetAccountPrivacy(in)
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if err != nil {
+		s.Logger.Error("Error set account privacy", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	// Tasdiqlangan so'rovchilar endi foydalanuvchini kuzatadi
+	if len(approved) > 0 {
+		s.forgetSuggestions(approved...)
+	}
+	return resp, nil
+}
+
+func (s *UserService) ListFollowRequests(ctx context.Context, in *pb.ListFollowRequestsRequest) (*pb.ListFollowRequestsResponse, error) {
+	resp, err := s.UserRepo.GetFollowRequests(in)
+	if err != nil {
+		s.Logger.Error("Error list follow requests", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *UserService) ApproveFollowRequest(ctx context.Context, in *pb.ApproveFollowRequestRequest) (*pb.ApproveFollowRequestResponse, error) {
+	resp, err := s.UserRepo.ApproveFollowRequest(in)
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.NotFound, "follow request not found")
+	}
+	if err != nil {
+		s.Logger.Error("Error approve follow request", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	s.forgetSuggestions(in.RequesterId)
+	return resp, nil
+}
+
+func (s *UserService) RejectFollowRequest(ctx context.Context, in *pb.RejectFollowRequestRequest) (*pb.RejectFollowRequestResponse, error) {
+	resp, err := s.UserRepo.RejectFollowRequest(in)
+	if err != nil {
+		s.Logger.Error("Error reject follow request", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"auth-service/models"
+	"auth-service/pkg/mailer"
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	// outboxBatchSize is how many emails one poll claims.
+	outboxBatchSize = 20
+	// outboxSendTimeout bounds a single delivery attempt.
+	outboxSendTimeout = 30 * time.Second
+	// outboxLease keeps a claimed email away from other workers while it is
+	// being sent. It is longer than outboxSendTimeout.
+	outboxLease = 2 * time.Minute
+
+	outboxBaseDelay = 30 * time.Second
+	outboxMaxDelay  = 6 * time.Hour
+
+	// outboxPurgeInterval is how often sent and dead emails older than
+	// Retention are deleted.
+	outboxPurgeInterval = time.Hour
+)
+
+// OutboxStore is the part of the user repository the outbox worker uses.
+type OutboxStore interface {
+	ClaimDueEmails(limit int, lease time.Duration) ([]models.OutboxEmail, error)
+	MarkEmailSent(id string) error
+	MarkEmailFailed(id, lastError string, nextAttemptAt time.Time, dead bool) error
+	PurgeOutboxEmails(before time.Time) (int64, error)
+}
+
+// OutboxWorker delivers the emails queued in email_outbox. A failed email is
+// retried with exponential backoff and becomes dead after MaxAttempts. Sent
+// and dead emails are deleted once they are older than Retention.
+type OutboxWorker struct {
+	Store       OutboxStore
+	Mailer      mailer.Mailer
+	Logger      *slog.Logger
+	MaxAttempts int
+	Interval    time.Duration
+	Retention   time.Duration
+}
+
+// Run polls the outbox every Interval until ctx is done.
+func (w *OutboxWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	var lastPurge time.Time
+	for {
+		if time.Since(lastPurge) >= outboxPurgeInterval {
+			w.purge()
+			lastPurge = time.Now()
+		}
+
+		// To'liq batch kelgan bo'lsa navbatda yana xatlar bor, kutmasdan davom etamiz
+		for {
+			n, err := w.deliverDue(ctx)
+			if err != nil {
+				w.Logger.Error("Error delivering outbox emails", slog.String("error", err.Error()))
+				break
+			}
+			if n < outboxBatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge deletes the sent and dead emails older than Retention.
+func (w *OutboxWorker) purge() {
+	n, err := w.Store.PurgeOutboxEmails(time.Now().Add(-w.Retention))
+	if err != nil {
+		w.Logger.Error("Error purging outbox emails", slog.String("error", err.Error()))
+		return
+	}
+	if n > 0 {
+		w.Logger.Info("Purged outbox emails", slog.Int64("count", n))
+	}
+}
+
+// deliverDue sends the emails that are due and returns how many it claimed.
+func (w *OutboxWorker) deliverDue(ctx context.Context) (int, error) {
+	emails, err := w.Store.ClaimDueEmails(outboxBatchSize, outboxLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, email := range emails {
+		if err := w.deliver(ctx, email); err != nil {
+			return len(emails), err
+		}
+	}
+
+	return len(emails), nil
+}
+
+func (w *OutboxWorker) deliver(ctx context.Context, email models.OutboxEmail) error {
+	sendCtx, cancel := context.WithTimeout(ctx, outboxSendTimeout)
+	defer cancel()
+
+	err := w.Mailer.Send(sendCtx, mailer.Message{
+		To:      email.Recipient,
+		Subject: email.Subject,
+		Text:    email.TextBody,
+		HTML:    email.HTMLBody,
+	})
+	if err == nil {
+		return w.Store.MarkEmailSent(email.ID)
+	}
+
+	dead := email.Attempts >= w.MaxAttempts
+	if dead {
+		w.Logger.Error("Outbox email is dead", slog.String("id", email.ID), slog.String("error", err.Error()))
+	} else {
+		w.Logger.Warn("Error sending outbox email", slog.String("id", email.ID), slog.String("error", err.Error()))
+	}
+
+	return w.Store.MarkEmailFailed(email.ID, err.Error(), time.Now().Add(outboxBackoff(email.Attempts)), dead)
+}
+
+// outboxBackoff is the delay after the given failed attempt: 30s, 1m, 2m, ...
+// up to 6h.
+func outboxBackoff(attempt int) time.Duration {
+	delay := outboxBaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= outboxMaxDelay {
+			return outboxMaxDelay
+		}
+	}
+	return delay
+}
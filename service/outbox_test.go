@@ -0,0 +1,99 @@
+package service
+
+import (
+	"auth-service/models"
+	"auth-service/pkg/mailer"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeOutbox struct {
+	due    []models.OutboxEmail
+	sent   []string
+	failed map[string]bool
+	purged time.Time
+}
+
+func (f *fakeOutbox) ClaimDueEmails(limit int, lease time.Duration) ([]models.OutboxEmail, error) {
+	due := f.due
+	f.due = nil
+	return due, nil
+}
+
+func (f *fakeOutbox) MarkEmailSent(id string) error {
+	f.sent = append(f.sent, id)
+	return nil
+}
+
+func (f *fakeOutbox) MarkEmailFailed(id, lastError string, nextAttemptAt time.Time, dead bool) error {
+	f.failed[id] = dead
+	return nil
+}
+
+func (f *fakeOutbox) PurgeOutboxEmails(before time.Time) (int64, error) {
+	f.purged = before
+	return 0, nil
+}
+
+type fakeMailer struct {
+	fail map[string]bool
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg mailer.Message) error {
+	if m.fail[msg.To] {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestOutboxWorkerDeliverDue(t *testing.T) {
+	store := &fakeOutbox{
+		due: []models.OutboxEmail{
+			{ID: "1", Recipient: "ok@example.com", Attempts: 1},
+			{ID: "2", Recipient: "down@example.com", Attempts: 2},
+			{ID: "3", Recipient: "down@example.com", Attempts: 3},
+		},
+		failed: map[string]bool{},
+	}
+	w := &OutboxWorker{
+		Store:       store,
+		Mailer:      &fakeMailer{fail: map[string]bool{"down@example.com": true}},
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		MaxAttempts: 3,
+	}
+
+	n, err := w.deliverDue(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []string{"1"}, store.sent)
+	assert.Equal(t, map[string]bool{"2": false, "3": true}, store.failed)
+}
+
+func TestOutboxWorkerPurge(t *testing.T) {
+	store := &fakeOutbox{}
+	w := &OutboxWorker{
+		Store:     store,
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Retention: 24 * time.Hour,
+	}
+
+	w.purge()
+
+	assert.WithinDuration(t, time.Now().Add(-24*time.Hour), store.purged, time.Minute)
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, outboxBackoff(1))
+	assert.Equal(t, time.Minute, outboxBackoff(2))
+	assert.Equal(t, 4*time.Minute, outboxBackoff(4))
+	assert.Equal(t, outboxMaxDelay, outboxBackoff(20))
+}
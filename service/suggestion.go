@@ -0,0 +1,80 @@
+package service
+
+import (
+	"auth-service/config"
+	pb "auth-service/generated/user"
+	"context"
+	"log/slog"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultSuggestions = 20
+	// maxSuggestions are ranked and cached at once, smaller limits are cut
+	// from the same list.
+	maxSuggestions = 50
+)
+
+// SuggestUsers returns "people you may know" for user_id. The ranking is
+// cached in Redis for SUGGESTIONS_CACHE_TTL, or until the user follows,
+// unfollows, blocks or mutes someone.
+func (s *UserService) SuggestUsers(ctx context.Context, in *pb.SuggestUsersRequest) (*pb.SuggestUsersResponse, error) {
+	limit := int(in.Limit)
+	if limit <= 0 {
+		limit = defaultSuggestions
+	}
+	if limit > maxSuggestions {
+		limit = maxSuggestions
+	}
+
+	users, err := s.suggestions(in.UserId)
+	if err != nil {
+		s.Logger.Error("Error suggest users", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if len(users) > limit {
+		users = users[:limit]
+	}
+
+	return &pb.SuggestUsersResponse{Users: users}, nil
+}
+
+// suggestions returns the cached ranking of the user, or ranks and caches it.
+// Redis errors are logged and the ranking is read from the database.
+func (s *UserService) suggestions(userID string) ([]*pb.SuggestedUser, error) {
+	data, found, err := s.RedisClient.GetSuggestions(userID)
+	if err != nil {
+		s.Logger.Error("Error getting cached suggestions", slog.String("error", err.Error()))
+	}
+	if found {
+		var cached pb.SuggestUsersResponse
+		if err := proto.Unmarshal(data, &cached); err == nil {
+			return cached.Users, nil
+		}
+	}
+
+	users, err := s.UserRepo.SuggestUsers(userID, maxSuggestions)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = proto.Marshal(&pb.SuggestUsersResponse{Users: users})
+	if err == nil {
+		err = s.RedisClient.SaveSuggestions(userID, data, config.Load().SUGGESTIONS_CACHE_TTL)
+	}
+	if err != nil {
+		s.Logger.Error("Error caching suggestions", slog.String("error", err.Error()))
+	}
+
+	return users, nil
+}
+
+// forgetSuggestions drops the cached rankings of users whose follows, blocks
+// or mutes changed.
+func (s *UserService) forgetSuggestions(userIDs ...string) {
+	if err := s.RedisClient.ClearSuggestions(userIDs...); err != nil {
+		s.Logger.Error("Error clearing cached suggestions", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"auth-service/auth"
+	pb "auth-service/generated/user"
+	"context"
+	"errors"
+	"log/slog"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *UserService) ValidateToken(ctx context.Context, in *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
+	claims, err := s.Verifier.Verify(in.Token)
+	if errors.Is(err, auth.ErrInvalidToken) {
+		return &pb.ValidateTokenResponse{Active: false}, nil
+	}
+	if err != nil {
+		s.Logger.Error("Error in validate token", slog.String("error", err.Error()))
+		return nil, status.Error(codes.Internal, "failed to validate token")
+	}
+
+	return &pb.ValidateTokenResponse{
+		Active:    true,
+		UserId:    claims.UserId,
+		Username:  claims.Username,
+		Email:     claims.Email,
+		SessionId: claims.SessionId,
+		Role:      claims.Role,
+		ClientId:  claims.ClientId,
+		Scope:     claims.Scope,
+		IssuedAt:  claims.IssuedAt,
+		ExpiresAt: claims.ExpiresAt,
+	}, nil
+}
@@ -1,11 +1,20 @@
 package service
 
 import (
+	"auth-service/auth"
+	"auth-service/config"
 	pb "auth-service/generated/user"
+	"auth-service/models"
+	"auth-service/pkg/mailer"
 	"auth-service/storage/postgres"
 	"auth-service/storage/redis"
 	"context"
+	"database/sql"
 	"log/slog"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type UserService struct {
@@ -13,10 +22,14 @@ type UserService struct {
 	UserRepo    *postgres.UserRepo
 	Logger      *slog.Logger
 	RedisClient *redis.RedisClient
+	Verifier    *auth.Verifier
 }
 
 func (s *UserService) UserInfo(ctx context.Context, in *pb.UserInfoRequest) (*pb.UserInfoResponse, error) {
-	resp, err := s.UserRepo.GetUserInfo(in.Id)
+	resp, err := s.UserRepo.GetUserInfo(in.Id, viewerID(ctx))
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
 	if err != nil {
 		s.Logger.Error("Userni ma'lumotlarini olishda xatolik", slog.String("error", err.Error()))
 		return nil, err
@@ -25,8 +38,21 @@ func (s *UserService) UserInfo(ctx context.Context, in *pb.UserInfoRequest) (*pb
 	return resp, nil
 }
 
+// viewerID is the id of the user calling, or "" for service clients, which
+// see every user.
+func viewerID(ctx context.Context) string {
+	principal, ok := auth.FromContext(ctx)
+	if !ok || principal.Machine() {
+		return ""
+	}
+	return principal.UserID
+}
+
 func (s *UserService) GetUserProfile(ctx context.Context, in *pb.GetProfileRequest) (*pb.GetProfileResponse, error) {
-	resp, err := s.UserRepo.GetUserProfile(in.Id)
+	resp, err := s.UserRepo.GetUserProfile(in.Id, viewerID(ctx))
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
 	if err != nil {
 		s.Logger.Error("Xatolik get user pofilda serviceda", slog.String("error", err.Error()))
 		return nil, err
@@ -45,7 +71,7 @@ func (s *UserService) UpdateUserProfile(ctx context.Context, in *pb.UpdateProfil
 }
 
 func (s *UserService) ListUsers(ctx context.Context, in *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
-	resp, err := s.UserRepo.GetUsers(in)
+	resp, err := s.UserRepo.GetUsers(in, viewerID(ctx))
 	if err != nil {
 		s.Logger.Error("Error userlar ro'yxatini olishda", slog.String("error", err.Error()))
 		return nil, err
@@ -55,7 +81,32 @@ func (s *UserService) ListUsers(ctx context.Context, in *pb.ListUsersRequest) (*
 }
 
 func (s *UserService) DeleteUser(ctx context.Context, in *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
-	resp, err := s.UserRepo.DeleteUser(in.Id)
+	// Email manzil o'chirishdan oldin olinadi, keyin user topilmaydi
+	user, err := s.UserRepo.GetUserByID(in.Id)
+	if err != nil && err != sql.ErrNoRows {
+		s.Logger.Error("Userni olishda xatolik", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	var farewell *models.OutboxEmail
+	if user != nil {
+		msg, err := mailer.Render(mailer.AccountDeleted, requestLocale(ctx), mailer.Data{
+			Username: user.Username,
+		})
+		if err != nil {
+			s.Logger.Error("Error rendering account deleted email", slog.String("error", err.Error()))
+			return nil, err
+		}
+
+		farewell = &models.OutboxEmail{
+			Recipient: user.Email,
+			Subject:   msg.Subject,
+			TextBody:  msg.Text,
+			HTMLBody:  msg.HTML,
+		}
+	}
+
+	resp, err := s.UserRepo.DeleteUser(in.Id, farewell)
 	if err != nil {
 		s.Logger.Error("Userni o'chirishda xatolik", slog.String("error", err.Error()))
 		return nil, err
@@ -64,17 +115,45 @@ func (s *UserService) DeleteUser(ctx context.Context, in *pb.DeleteUserRequest)
 	return resp, nil
 }
 
+// requestLocale picks the email locale from the Accept-Language the caller
+// forwarded in the metadata, as grpc-gateway does.
+func requestLocale(ctx context.Context) string {
+	var acceptLanguage string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, key := range []string{"accept-language", "grpcgateway-accept-language"} {
+			if values := md.Get(key); len(values) > 0 {
+				acceptLanguage = values[0]
+				break
+			}
+		}
+	}
+
+	return mailer.Locale(acceptLanguage, config.Load().MAIL_DEFAULT_LOCALE)
+}
+
 func (s *UserService) FollowUser(ctx context.Context, in *pb.FollowUserRequest) (*pb.FollowUserResponse, error) {
+	if in.FollowerId == in.FollowingId {
+		return nil, status.Error(codes.InvalidArgument, "users cannot follow themselves")
+	}
+
 	resp, err := s.UserRepo.FollowingUser(in)
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if err == postgres.ErrBlocked {
+		return nil, status.Error(codes.FailedPrecondition, "user cannot be followed")
+	}
 	if err != nil {
 		s.Logger.Error("Userga follower bo'lishda xatolik", slog.String("error", err.Error()))
 		return nil, err
 	}
+
+	s.forgetSuggestions(in.FollowerId)
 	return resp, nil
 }
 
 func (s *UserService) ListFollowers(ctx context.Context, in *pb.ListFollowersRequest) (*pb.ListFollowersResponse, error) {
-	resp, err := s.UserRepo.GetFollowers(in)
+	resp, err := s.UserRepo.GetFollowers(in, viewerID(ctx))
 	if err != nil {
 		s.Logger.Error("userni followerlarini olishda xatolik", slog.String("error", err.Error()))
 		return nil, err
@@ -82,8 +161,56 @@ func (s *UserService) ListFollowers(ctx context.Context, in *pb.ListFollowersReq
 	return resp, nil
 }
 
+func (s *UserService) UnfollowUser(ctx context.Context, in *pb.UnfollowUserRequest) (*pb.UnfollowUserResponse, error) {
+	resp, err := s.UserRepo.UnfollowUser(in)
+	if err != nil {
+		s.Logger.Error("Error unfollow user", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	s.forgetSuggestions(in.FollowerId)
+	return resp, nil
+}
+
+func (s *UserService) ListFollowing(ctx context.Context, in *pb.ListFollowingRequest) (*pb.ListFollowingResponse, error) {
+	resp, err := s.UserRepo.GetFollowing(in, viewerID(ctx))
+	if err != nil {
+		s.Logger.Error("userni following ro'yxatini olishda xatolik", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *UserService) GetRelationship(ctx context.Context, in *pb.GetRelationshipRequest) (*pb.GetRelationshipResponse, error) {
+	resp, err := s.UserRepo.GetRelationship(in)
+	if err != nil {
+		s.Logger.Error("Error get relationship", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return resp, nil
+}
+
+// maxCheckFollows is how many users one CheckFollows call can ask about.
+const maxCheckFollows = 100
+
+func (s *UserService) CheckFollows(ctx context.Context, in *pb.CheckFollowsRequest) (*pb.CheckFollowsResponse, error) {
+	if len(in.UserIds) > maxCheckFollows {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d user ids can be checked at once", maxCheckFollows)
+	}
+
+	resp, err := s.UserRepo.CheckFollows(in)
+	if err != nil {
+		s.Logger.Error("Error check follows", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return resp, nil
+}
+
 func (s *UserService) GetUserActivity(ctx context.Context, in *pb.GetUserActivityRequest) (*pb.GetUserActivityResponse, error) {
-	resp, err := s.UserRepo.GetUserActivity(in.Id)
+	resp, err := s.UserRepo.GetUserActivity(in.Id, viewerID(ctx))
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
 	if err != nil {
 		s.Logger.Error("Error in get user activity", slog.String("error", err.Error()))
 		return nil, err
@@ -0,0 +1,233 @@
+package postgres
+
+import (
+	pb "auth-service/generated/user"
+	"auth-service/models"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrBlocked is returned when one of two users has blocked the other.
+var ErrBlocked = errors.New("one of the users has blocked the other")
+
+// queryRower is a *sql.DB or a *sql.Tx.
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// dbtx is a *sql.DB or a *sql.Tx, for helpers that both read and write.
+type dbtx interface {
+	execer
+	queryRower
+}
+
+// viewerParam is the query parameter of the user a list or profile is shown
+// to. Requests without a user, such as those of service clients, pass NULL,
+// which matches no block.
+func viewerParam(viewerID string) interface{} {
+	if viewerID == "" {
+		return nil
+	}
+	return viewerID
+}
+
+// lockUsers locks the rows of both users until the transaction ends, in id
+// order. Follows, approvals and blocks between two users take this lock
+// first, so a follow cannot be added while a block between them commits.
+func lockUsers(db execer, userID, otherID string) error {
+	_, err := db.Exec(`
+		SELECT
+			id
+		FROM
+			users
+		WHERE
+			id IN ($1, $2)
+		ORDER BY
+			id
+		FOR UPDATE
+	`, userID, otherID)
+	return err
+}
+
+// addBlock stores a block or mute of targetID by userID and returns when it
+// was made. Doing it twice keeps the first one. sql.ErrNoRows is returned if
+// the target does not exist or is deleted.
+func addBlock(db queryRower, userID, targetID, kind string) (time.Time, error) {
+	var createdAt time.Time
+
+	err := db.QueryRow(`
+		WITH inserted AS (
+			INSERT INTO blocks (
+				blocker_id,
+				blocked_id,
+				kind
+			)
+			SELECT
+				$1,
+				$2,
+				$3
+			WHERE
+				EXISTS (SELECT 1 FROM users WHERE id = $2 AND deleted_at = 0)
+			ON CONFLICT (blocker_id, blocked_id, kind) DO NOTHING
+			RETURNING
+				created_at
+		)
+		SELECT
+			created_at
+		FROM
+			inserted
+		UNION ALL
+		SELECT
+			created_at
+		FROM
+			blocks
+		WHERE
+			blocker_id = $1 AND blocked_id = $2 AND kind = $3
+		LIMIT 1
+	`, userID, targetID, kind).Scan(&createdAt)
+
+	return createdAt, err
+}
+
+func (repo *UserRepo) removeBlock(userID, targetID, kind string) (bool, error) {
+	res, err := repo.DB.Exec(`
+		DELETE FROM
+			blocks
+		WHERE
+			blocker_id = $1 AND blocked_id = $2 AND kind = $3
+	`, userID, targetID, kind)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// BlockUser blocks blocked_id for blocker_id and removes the follows and
+// follow requests between them in both directions, in one transaction.
+func (repo *UserRepo) BlockUser(req *pb.BlockUserRequest) (*pb.BlockUserResponse, error) {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockUsers(tx, req.BlockerId, req.BlockedId); err != nil {
+		return nil, err
+	}
+
+	blockedAt, err := addBlock(tx, req.BlockerId, req.BlockedId, models.BlockKindBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = removeFollows(tx, "(follower_id = $1 AND following_id = $2) OR (follower_id = $2 AND following_id = $1)",
+		req.BlockerId, req.BlockedId)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM
+			follow_requests
+		WHERE
+			(requester_id = $1 AND target_id = $2) OR (requester_id = $2 AND target_id = $1)
+	`, req.BlockerId, req.BlockedId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &pb.BlockUserResponse{
+		BlockerId: req.BlockerId,
+		BlockedId: req.BlockedId,
+		BlockedAt: blockedAt.Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+// UnblockUser lifts a block. Earlier follows are not restored.
+func (repo *UserRepo) UnblockUser(req *pb.UnblockUserRequest) (*pb.UnblockUserResponse, error) {
+	unblocked, err := repo.removeBlock(req.BlockerId, req.BlockedId, models.BlockKindBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.UnblockUserResponse{
+		BlockerId: req.BlockerId,
+		BlockedId: req.BlockedId,
+		Unblocked: unblocked,
+	}, nil
+}
+
+func (repo *UserRepo) MuteUser(req *pb.MuteUserRequest) (*pb.MuteUserResponse, error) {
+	mutedAt, err := addBlock(repo.DB, req.MuterId, req.MutedId, models.BlockKindMute)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.MuteUserResponse{
+		MuterId: req.MuterId,
+		MutedId: req.MutedId,
+		MutedAt: mutedAt.Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+func (repo *UserRepo) UnmuteUser(req *pb.UnmuteUserRequest) (*pb.UnmuteUserResponse, error) {
+	unmuted, err := repo.removeBlock(req.MuterId, req.MutedId, models.BlockKindMute)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.UnmuteUserResponse{
+		MuterId: req.MuterId,
+		MutedId: req.MutedId,
+		Unmuted: unmuted,
+	}, nil
+}
+
+// IsBlocked reports the blocks and mutes between user_id and other_id.
+// Blocked is set if either of them blocked the other.
+func (repo *UserRepo) IsBlocked(req *pb.IsBlockedRequest) (*pb.IsBlockedResponse, error) {
+	resp := pb.IsBlockedResponse{
+		UserId:  req.UserId,
+		OtherId: req.OtherId,
+	}
+
+	err := repo.DB.QueryRow(`
+		SELECT
+			EXISTS (SELECT 1 FROM blocks WHERE blocker_id = $1 AND blocked_id = $2 AND kind = 'block'),
+			EXISTS (SELECT 1 FROM blocks WHERE blocker_id = $2 AND blocked_id = $1 AND kind = 'block'),
+			EXISTS (SELECT 1 FROM blocks WHERE blocker_id = $1 AND blocked_id = $2 AND kind = 'mute')
+	`, req.UserId, req.OtherId).Scan(&resp.UserBlockedOther, &resp.OtherBlockedUser, &resp.UserMutedOther)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Blocked = resp.UserBlockedOther || resp.OtherBlockedUser
+
+	return &resp, nil
+}
+
+// blockedBetween reports whether either user blocked the other.
+func blockedBetween(db queryRower, userID, otherID string) (bool, error) {
+	var blocked bool
+
+	err := db.QueryRow(`
+		SELECT
+			EXISTS (
+				SELECT 1 FROM blocks
+				WHERE kind = 'block' AND ((blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1))
+			)
+	`, userID, otherID).Scan(&blocked)
+
+	return blocked, err
+}
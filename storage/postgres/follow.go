@@ -0,0 +1,440 @@
+package postgres
+
+import (
+	pb "auth-service/generated/user"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// FollowingUser makes follower_id follow following_id. If the followed user
+// is private, a follow request is stored instead and the response is pending
+// until they approve it. Following twice is not an error, the existing follow
+// or request is returned. sql.ErrNoRows is returned if the followed user does
+// not exist or is deleted, ErrBlocked if either user blocked the other.
+func (repo *UserRepo) FollowingUser(req *pb.FollowUserRequest) (*pb.FollowUserResponse, error) {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockUsers(tx, req.FollowerId, req.FollowingId); err != nil {
+		return nil, err
+	}
+
+	var isPrivate bool
+	err = tx.QueryRow(`
+		SELECT
+			is_private
+		FROM
+			users
+		WHERE
+			id = $1 AND deleted_at = 0
+	`, req.FollowingId).Scan(&isPrivate)
+	if err != nil {
+		return nil, err
+	}
+
+	blocked, err := blockedBetween(tx, req.FollowerId, req.FollowingId)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrBlocked
+	}
+
+	follower := &pb.FollowUserResponse{
+		FollowerId:  req.FollowerId,
+		FollowingId: req.FollowingId,
+	}
+
+	// Yopiq akkauntga avval so'rov yuboriladi, agar hali follow qilinmagan bo'lsa
+	if isPrivate {
+		err = tx.QueryRow(`
+			SELECT
+				followed_at
+			FROM
+				followers
+			WHERE
+				follower_id = $1 AND following_id = $2
+		`, req.FollowerId, req.FollowingId).Scan(&follower.FollowingAt)
+		if err == nil {
+			return follower, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		follower.FollowingAt, err = addFollowRequest(tx, req.FollowerId, req.FollowingId)
+		if err != nil {
+			return nil, err
+		}
+		follower.Pending = true
+	} else {
+		follower.FollowingAt, err = addFollow(tx, req.FollowerId, req.FollowingId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return follower, nil
+}
+
+// addFollow stores the follow unless it exists and returns when it was made.
+// A new follow is added to the counts of both users.
+func addFollow(db dbtx, followerID, followingID string) (string, error) {
+	var (
+		followedAt string
+		inserted   bool
+	)
+
+	// Yangi qator qo'shilmasa, mavjud follow qaytariladi
+	err := db.QueryRow(`
+		WITH inserted AS (
+			INSERT INTO followers (
+				follower_id,
+				following_id
+			)
+			VALUES (
+				$1,
+				$2
+			)
+			ON CONFLICT (follower_id, following_id) DO NOTHING
+			RETURNING
+				followed_at
+		)
+		SELECT
+			followed_at,
+			TRUE
+		FROM
+			inserted
+		UNION ALL
+		SELECT
+			followed_at,
+			FALSE
+		FROM
+			followers
+		WHERE
+			follower_id = $1 AND following_id = $2
+		LIMIT 1
+	`, followerID, followingID).Scan(&followedAt, &inserted)
+	if err != nil {
+		return "", err
+	}
+
+	if inserted {
+		_, err = db.Exec(`
+			UPDATE
+				users
+			SET
+				following_count = following_count + CASE WHEN id = $1 THEN 1 ELSE 0 END,
+				followers_count = followers_count + CASE WHEN id = $2 THEN 1 ELSE 0 END
+			WHERE
+				id IN ($1, $2)
+		`, followerID, followingID)
+	}
+
+	return followedAt, err
+}
+
+// removeFollows deletes the follows matching where and takes each of them off
+// the counts of both users. It reports whether any follow was removed.
+func removeFollows(db execer, where string, args ...interface{}) (bool, error) {
+	res, err := db.Exec(`
+		WITH removed AS (
+			DELETE FROM
+				followers
+			WHERE
+				`+where+`
+			RETURNING
+				follower_id,
+				following_id
+		)
+		UPDATE
+			users u
+		SET
+			following_count = following_count - (SELECT COUNT(*) FROM removed r WHERE r.follower_id = u.id),
+			followers_count = followers_count - (SELECT COUNT(*) FROM removed r WHERE r.following_id = u.id)
+		WHERE
+			u.id IN (SELECT follower_id FROM removed UNION SELECT following_id FROM removed)
+	`, args...)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// addFollowRequest stores the follow request unless it exists and returns
+// when it was made.
+func addFollowRequest(db queryRower, requesterID, targetID string) (string, error) {
+	var requestedAt string
+
+	err := db.QueryRow(`
+		WITH inserted AS (
+			INSERT INTO follow_requests (
+				requester_id,
+				target_id
+			)
+			VALUES (
+				$1,
+				$2
+			)
+			ON CONFLICT (requester_id, target_id) DO NOTHING
+			RETURNING
+				requested_at
+		)
+		SELECT
+			requested_at
+		FROM
+			inserted
+		UNION ALL
+		SELECT
+			requested_at
+		FROM
+			follow_requests
+		WHERE
+			requester_id = $1 AND target_id = $2
+		LIMIT 1
+	`, requesterID, targetID).Scan(&requestedAt)
+
+	return requestedAt, err
+}
+
+// UnfollowUser removes the follow, or withdraws the pending follow request.
+// Unfollowing a user that is not followed is not an error, the response just
+// says nothing was removed.
+func (repo *UserRepo) UnfollowUser(req *pb.UnfollowUserRequest) (*pb.UnfollowUserResponse, error) {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	unfollowed, err := removeFollows(tx, "follower_id = $1 AND following_id = $2", req.FollowerId, req.FollowingId)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.Exec(`
+		DELETE FROM
+			follow_requests
+		WHERE
+			requester_id = $1 AND target_id = $2
+	`, req.FollowerId, req.FollowingId)
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawn, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &pb.UnfollowUserResponse{
+		FollowerId:  req.FollowerId,
+		FollowingId: req.FollowingId,
+		Unfollowed:  unfollowed || withdrawn > 0,
+	}, nil
+}
+
+// GetFollowers lists the followers of user_id, most recent first. Followers
+// who blocked viewerID are left out.
+func (repo *UserRepo) GetFollowers(req *pb.ListFollowersRequest, viewerID string) (*pb.ListFollowersResponse, error) {
+	var followers []*pb.Follower
+	offset := (req.Page - 1) * req.Limit
+	rows, err := repo.DB.Query(`
+		SELECT
+			u.id,
+			username,
+			full_name
+		FROM
+			users u
+		INNER JOIN
+			followers f ON u.id = f.follower_id
+		WHERE
+			f.following_id = $1 and deleted_at = 0 AND
+			NOT EXISTS (SELECT 1 FROM blocks b WHERE b.blocker_id = u.id AND b.blocked_id = $4 AND b.kind = 'block')
+		ORDER BY
+			f.followed_at DESC
+		OFFSET $2
+		LIMIT $3
+	`, req.UserId, offset, req.Limit, viewerParam(viewerID))
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var follower pb.Follower
+
+		err = rows.Scan(&follower.Id, &follower.Username, &follower.FullName)
+		if err != nil {
+			return nil, err
+		}
+
+		followers = append(followers, &follower)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var total int32
+	err = repo.DB.QueryRow(`
+		SELECT
+			COUNT(*)
+		FROM
+			users u
+		INNER JOIN
+			followers f ON u.id = f.follower_id
+		WHERE
+			f.following_id = $1 and u.deleted_at = 0 AND
+			NOT EXISTS (SELECT 1 FROM blocks b WHERE b.blocker_id = u.id AND b.blocked_id = $2 AND b.kind = 'block')
+	`, req.UserId, viewerParam(viewerID)).Scan(&total)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListFollowersResponse{
+		Followers: followers,
+		Total:     total,
+		Page:      req.Page,
+		Limit:     req.Limit,
+	}
+
+	return resp, nil
+}
+
+// GetFollowing lists the users user_id follows, most recent first. Users who
+// blocked viewerID are left out.
+func (repo *UserRepo) GetFollowing(req *pb.ListFollowingRequest, viewerID string) (*pb.ListFollowingResponse, error) {
+	var following []*pb.Follower
+	offset := (req.Page - 1) * req.Limit
+	rows, err := repo.DB.Query(`
+		SELECT
+			u.id,
+			username,
+			full_name
+		FROM
+			users u
+		INNER JOIN
+			followers f ON u.id = f.following_id
+		WHERE
+			f.follower_id = $1 and deleted_at = 0 AND
+			NOT EXISTS (SELECT 1 FROM blocks b WHERE b.blocker_id = u.id AND b.blocked_id = $4 AND b.kind = 'block')
+		ORDER BY
+			f.followed_at DESC
+		OFFSET $2
+		LIMIT $3
+	`, req.UserId, offset, req.Limit, viewerParam(viewerID))
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user pb.Follower
+
+		err = rows.Scan(&user.Id, &user.Username, &user.FullName)
+		if err != nil {
+			return nil, err
+		}
+
+		following = append(following, &user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var total int32
+	err = repo.DB.QueryRow(`
+		SELECT
+			COUNT(*)
+		FROM
+			users u
+		INNER JOIN
+			followers f ON u.id = f.following_id
+		WHERE
+			f.follower_id = $1 and u.deleted_at = 0 AND
+			NOT EXISTS (SELECT 1 FROM blocks b WHERE b.blocker_id = u.id AND b.blocked_id = $2 AND b.kind = 'block')
+	`, req.UserId, viewerParam(viewerID)).Scan(&total)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ListFollowingResponse{
+		Following: following,
+		Total:     total,
+		Page:      req.Page,
+		Limit:     req.Limit,
+	}, nil
+}
+
+// GetRelationship reports whether user_id and other_id follow each other.
+func (repo *UserRepo) GetRelationship(req *pb.GetRelationshipRequest) (*pb.GetRelationshipResponse, error) {
+	resp := pb.GetRelationshipResponse{
+		UserId:  req.UserId,
+		OtherId: req.OtherId,
+	}
+
+	err := repo.DB.QueryRow(`
+		SELECT
+			EXISTS (SELECT 1 FROM followers WHERE follower_id = $1 AND following_id = $2),
+			EXISTS (SELECT 1 FROM followers WHERE follower_id = $2 AND following_id = $1)
+	`, req.UserId, req.OtherId).Scan(&resp.Following, &resp.FollowedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Mutual = resp.Following && resp.FollowedBy
+
+	return &resp, nil
+}
+
+// CheckFollows returns which of user_ids follower_id follows.
+func (repo *UserRepo) CheckFollows(req *pb.CheckFollowsRequest) (*pb.CheckFollowsResponse, error) {
+	rows, err := repo.DB.Query(`
+		SELECT
+			following_id
+		FROM
+			followers
+		WHERE
+			follower_id = $1 AND following_id::TEXT = ANY($2)
+	`, req.FollowerId, pq.Array(req.UserIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resp := pb.CheckFollowsResponse{
+		FollowerId:   req.FollowerId,
+		FollowingIds: []string{},
+	}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		resp.FollowingIds = append(resp.FollowingIds, id)
+	}
+
+	return &resp, rows.Err()
+}
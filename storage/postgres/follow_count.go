@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"auth-service/models"
+)
+
+// followCounts computes the follow counts of every user from the followers
+// table, next to the stored ones. Follows of deleted users are not counted.
+const followCounts = `
+	WITH actual AS (
+		SELECT
+			u.id,
+			u.username,
+			u.followers_count,
+			u.following_count,
+			(
+				SELECT COUNT(*) FROM followers f
+				INNER JOIN users other ON other.id = f.follower_id
+				WHERE f.following_id = u.id AND other.deleted_at = 0
+			) AS actual_followers,
+			(
+				SELECT COUNT(*) FROM followers f
+				INNER JOIN users other ON other.id = f.following_id
+				WHERE f.follower_id = u.id AND other.deleted_at = 0
+			) AS actual_following
+		FROM
+			users u
+		WHERE
+			u.deleted_at = 0
+	)
+`
+
+// ReconcileFollowCounts returns the users whose followers_count or
+// following_count differ from the followers table. With fix, the counts are
+// corrected in the same transaction; follows wait for it to finish so no
+// change is lost.
+func (repo *UserRepo) ReconcileFollowCounts(fix bool) ([]models.FollowCountDrift, error) {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := followCounts + `
+		SELECT
+			id,
+			username,
+			followers_count,
+			actual_followers,
+			following_count,
+			actual_following
+		FROM
+			actual
+		WHERE
+			followers_count <> actual_followers OR following_count <> actual_following
+		ORDER BY
+			username
+	`
+	if fix {
+		if _, err := tx.Exec(`LOCK TABLE followers IN SHARE MODE`); err != nil {
+			return nil, err
+		}
+
+		query = followCounts + `
+			UPDATE
+				users u
+			SET
+				followers_count = a.actual_followers,
+				following_count = a.actual_following
+			FROM
+				actual a
+			WHERE
+				u.id = a.id AND (a.followers_count <> a.actual_followers OR a.following_count <> a.actual_following)
+			RETURNING
+				a.id,
+				a.username,
+				a.followers_count,
+				a.actual_followers,
+				a.following_count,
+				a.actual_following
+		`
+	}
+
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drift := []models.FollowCountDrift{}
+	for rows.Next() {
+		var d models.FollowCountDrift
+		err := rows.Scan(&d.UserID, &d.Username, &d.FollowersCount, &d.ActualFollowers, &d.FollowingCount, &d.ActualFollowing)
+		if err != nil {
+			return nil, err
+		}
+		drift = append(drift, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return drift, nil
+}
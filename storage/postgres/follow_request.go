@@ -0,0 +1,256 @@
+package postgres
+
+import (
+	pb "auth-service/generated/user"
+	"database/sql"
+)
+
+// SetAccountPrivacy turns the private account setting of the user on or off.
+// Turning it off approves every pending follow request, since anyone may now
+// follow the user; the ids of the approved requesters are returned with the
+// response. sql.ErrNoRows is returned if the user does not exist.
+func (repo *UserRepo) SetAccountPrivacy(req *pb.SetAccountPrivacyRequest) (*pb.SetAccountPrivacyResponse, []string, error) {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE
+			users
+		SET
+			is_private = $2,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			id = $1 AND deleted_at = 0
+	`, req.UserId, req.IsPrivate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if rowsAffected == 0 {
+		return nil, nil, sql.ErrNoRows
+	}
+
+	resp := &pb.SetAccountPrivacyResponse{
+		UserId:    req.UserId,
+		IsPrivate: req.IsPrivate,
+	}
+
+	var approved []string
+	if !req.IsPrivate {
+		// Yangi followlar ikkala tomonning sonlariga qo'shiladi
+		rows, err := tx.Query(`
+			WITH approved AS (
+				INSERT INTO followers (
+					follower_id,
+					following_id
+				)
+				SELECT
+					r.requester_id,
+					r.target_id
+				FROM
+					follow_requests r
+				INNER JOIN
+					users u ON u.id = r.requester_id
+				WHERE
+					r.target_id = $1 AND u.deleted_at = 0
+				ON CONFLICT (follower_id, following_id) DO NOTHING
+				RETURNING
+					follower_id
+			),
+			following_counts AS (
+				UPDATE
+					users
+				SET
+					following_count = following_count + 1
+				WHERE
+					id IN (SELECT follower_id FROM approved)
+			),
+			followers_counts AS (
+				UPDATE
+					users
+				SET
+					followers_count = followers_count + (SELECT COUNT(*) FROM approved)
+				WHERE
+					id = $1
+			)
+			SELECT
+				follower_id
+			FROM
+				approved
+		`, req.UserId)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, nil, err
+			}
+			approved = append(approved, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, nil, err
+		}
+		resp.ApprovedRequests = int32(len(approved))
+
+		_, err = tx.Exec(`
+			DELETE FROM
+				follow_requests
+			WHERE
+				target_id = $1
+		`, req.UserId)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return resp, approved, nil
+}
+
+// GetFollowRequests lists the pending follow requests sent to user_id, most
+// recent first.
+func (repo *UserRepo) GetFollowRequests(req *pb.ListFollowRequestsRequest) (*pb.ListFollowRequestsResponse, error) {
+	var requests []*pb.FollowRequest
+	offset := (req.Page - 1) * req.Limit
+	rows, err := repo.DB.Query(`
+		SELECT
+			u.id,
+			username,
+			full_name,
+			r.requested_at
+		FROM
+			users u
+		INNER JOIN
+			follow_requests r ON u.id = r.requester_id
+		WHERE
+			r.target_id = $1 and deleted_at = 0
+		ORDER BY
+			r.requested_at DESC
+		OFFSET $2
+		LIMIT $3
+	`, req.UserId, offset, req.Limit)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var request pb.FollowRequest
+
+		err = rows.Scan(&request.RequesterId, &request.Username, &request.FullName, &request.RequestedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, &request)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var total int32
+	err = repo.DB.QueryRow(`
+		SELECT
+			COUNT(*)
+		FROM
+			users u
+		INNER JOIN
+			follow_requests r ON u.id = r.requester_id
+		WHERE
+			r.target_id = $1 and u.deleted_at = 0
+	`, req.UserId).Scan(&total)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ListFollowRequestsResponse{
+		Requests: requests,
+		Total:    total,
+		Page:     req.Page,
+		Limit:    req.Limit,
+	}, nil
+}
+
+// ApproveFollowRequest turns the follow request of requester_id into a
+// follow. sql.ErrNoRows is returned if there is no such request.
+func (repo *UserRepo) ApproveFollowRequest(req *pb.ApproveFollowRequestRequest) (*pb.ApproveFollowRequestResponse, error) {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockUsers(tx, req.UserId, req.RequesterId); err != nil {
+		return nil, err
+	}
+
+	var requestID string
+	err = tx.QueryRow(`
+		DELETE FROM
+			follow_requests
+		WHERE
+			target_id = $1 AND requester_id = $2
+		RETURNING
+			id
+	`, req.UserId, req.RequesterId).Scan(&requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	followedAt, err := addFollow(tx, req.RequesterId, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &pb.ApproveFollowRequestResponse{
+		FollowerId:  req.RequesterId,
+		FollowingId: req.UserId,
+		FollowingAt: followedAt,
+	}, nil
+}
+
+// RejectFollowRequest drops the follow request of requester_id. The requester
+// is not told and may ask again.
+func (repo *UserRepo) RejectFollowRequest(req *pb.RejectFollowRequestRequest) (*pb.RejectFollowRequestResponse, error) {
+	res, err := repo.DB.Exec(`
+		DELETE FROM
+			follow_requests
+		WHERE
+			target_id = $1 AND requester_id = $2
+	`, req.UserId, req.RequesterId)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RejectFollowRequestResponse{
+		UserId:      req.UserId,
+		RequesterId: req.RequesterId,
+		Rejected:    rowsAffected > 0,
+	}, nil
+}
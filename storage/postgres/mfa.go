@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"database/sql"
+)
+
+// SetPendingTOTPSecret stores a new encrypted TOTP secret that is not yet
+// enabled. sql.ErrNoRows is returned if two-factor authentication is already
+// on for the user.
+func (repo *UserRepo) SetPendingTOTPSecret(userID, encryptedSecret string) error {
+	res, err := repo.DB.Exec(`
+		UPDATE
+			users
+		SET
+			totp_secret = $1
+		WHERE
+			id = $2 AND deleted_at = 0 AND totp_enabled_at IS NULL
+	`, encryptedSecret, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetTOTPSecret returns the encrypted TOTP secret of the user and whether it
+// is enabled. The secret is empty if the user never started enrollment.
+func (repo *UserRepo) GetTOTPSecret(userID string) (string, bool, error) {
+	var (
+		secret  sql.NullString
+		enabled bool
+	)
+
+	err := repo.DB.QueryRow(`
+		SELECT
+			totp_secret,
+			totp_enabled_at IS NOT NULL
+		FROM
+			users
+		WHERE
+			id = $1 AND deleted_at = 0
+	`, userID).Scan(&secret, &enabled)
+
+	if err != nil {
+		return "", false, err
+	}
+
+	return secret.String, enabled, nil
+}
+
+// EnableTOTP turns two-factor authentication on and replaces the recovery
+// codes of the user with codeHashes.
+func (repo *UserRepo) EnableTOTP(userID string, codeHashes []string) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE
+			users
+		SET
+			totp_enabled_at = CURRENT_TIMESTAMP
+		WHERE
+			id = $1 AND totp_secret IS NOT NULL
+	`, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DisableTOTP turns two-factor authentication off and removes the secret and
+// the recovery codes.
+func (repo *UserRepo) DisableTOTP(userID string) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE
+			users
+		SET
+			totp_secret = NULL,
+			totp_enabled_at = NULL
+		WHERE
+			id = $1
+	`, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID string, codeHashes []string) error {
+	_, err := tx.Exec(`
+		DELETE FROM
+			recovery_codes
+		WHERE
+			user_id = $1
+	`, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, codeHash := range codeHashes {
+		_, err = tx.Exec(`
+			INSERT INTO recovery_codes (
+				user_id,
+				code_hash
+			)
+			VALUES (
+				$1,
+				$2
+			)
+		`, userID, codeHash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code of the user as used and
+// reports whether there was one.
+func (repo *UserRepo) UseRecoveryCode(userID, codeHash string) (bool, error) {
+	res, err := repo.DB.Exec(`
+		UPDATE
+			recovery_codes
+		SET
+			used_at = CURRENT_TIMESTAMP
+		WHERE
+			user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"auth-service/models"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func (repo *UserRepo) CreateOAuthClient(client models.OAuthClient) (*models.OAuthClient, error) {
+	var createdAt time.Time
+
+	err := repo.DB.QueryRow(`
+		INSERT INTO oauth_clients (
+			client_id,
+			client_secret_hash,
+			name,
+			redirect_uris,
+			scopes,
+			grant_types
+		)
+		VALUES (
+			$1,
+			NULLIF($2, ''),
+			$3,
+			$4,
+			$5,
+			$6
+		)
+		RETURNING
+			id,
+			created_at
+	`, client.ClientID, client.SecretHash, client.Name, pq.Array(client.RedirectURIs), pq.Array(client.Scopes),
+		pq.Array(client.GrantTypes)).
+		Scan(&client.ID, &createdAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.Confidential = client.SecretHash != ""
+	client.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+
+	return &client, nil
+}
+
+func (repo *UserRepo) GetOAuthClient(clientID string) (*models.OAuthClient, error) {
+	var (
+		client     models.OAuthClient
+		secretHash sql.NullString
+		createdAt  time.Time
+	)
+
+	err := repo.DB.QueryRow(`
+		SELECT
+			id,
+			client_id,
+			client_secret_hash,
+			name,
+			redirect_uris,
+			scopes,
+			grant_types,
+			created_at
+		FROM
+			oauth_clients
+		WHERE
+			client_id = $1 AND deleted_at = 0
+	`, clientID).Scan(&client.ID, &client.ClientID, &secretHash, &client.Name,
+		pq.Array(&client.RedirectURIs), pq.Array(&client.Scopes), pq.Array(&client.GrantTypes), &createdAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.SecretHash = secretHash.String
+	client.Confidential = secretHash.Valid
+	client.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+
+	return &client, nil
+}
+
+// GetConsentScopes returns the scopes the user has granted to the client.
+// sql.ErrNoRows is returned if the user never gave consent.
+func (repo *UserRepo) GetConsentScopes(userID, clientID string) ([]string, error) {
+	var scopes []string
+
+	err := repo.DB.QueryRow(`
+		SELECT
+			scopes
+		FROM
+			oauth_consents
+		WHERE
+			user_id = $1 AND client_id = $2
+	`, userID, clientID).Scan(pq.Array(&scopes))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scopes, nil
+}
+
+// SaveConsent records that the user granted scopes to the client. Scopes
+// granted earlier are kept.
+func (repo *UserRepo) SaveConsent(userID, clientID string, scopes []string) error {
+	_, err := repo.DB.Exec(`
+		INSERT INTO oauth_consents (
+			user_id,
+			client_id,
+			scopes
+		)
+		VALUES (
+			$1,
+			$2,
+			$3
+		)
+		ON CONFLICT (user_id, client_id) DO UPDATE
+		SET
+			scopes = ARRAY(SELECT DISTINCT UNNEST(oauth_consents.scopes || EXCLUDED.scopes)),
+			granted_at = CURRENT_TIMESTAMP
+	`, userID, clientID, pq.Array(scopes))
+	return err
+}
@@ -0,0 +1,238 @@
+package postgres
+
+import (
+	"auth-service/models"
+	"database/sql"
+	"time"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx, so an email can be queued
+// on its own or inside the transaction of the change it is about.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func enqueueEmail(db execer, email *models.OutboxEmail) error {
+	_, err := db.Exec(`
+		INSERT INTO email_outbox (
+			recipient,
+			subject,
+			text_body,
+			html_body
+		)
+		VALUES (
+			$1,
+			$2,
+			$3,
+			$4
+		)
+	`, email.Recipient, email.Subject, email.TextBody, email.HTMLBody)
+	return err
+}
+
+// EnqueueEmail queues an email that is not tied to any other change.
+func (repo *UserRepo) EnqueueEmail(email *models.OutboxEmail) error {
+	return enqueueEmail(repo.DB, email)
+}
+
+// ClaimDueEmails returns up to limit pending emails whose next attempt is due
+// and counts the attempt. Claimed emails are not due again for lease, so
+// another worker does not pick them up while they are being sent.
+func (repo *UserRepo) ClaimDueEmails(limit int, lease time.Duration) ([]models.OutboxEmail, error) {
+	rows, err := repo.DB.Query(`
+		UPDATE
+			email_outbox
+		SET
+			attempts = attempts + 1,
+			next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		WHERE
+			id IN (
+				SELECT
+					id
+				FROM
+					email_outbox
+				WHERE
+					status = 'pending' AND next_attempt_at <= NOW()
+				ORDER BY
+					next_attempt_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+		RETURNING
+			id,
+			recipient,
+			subject,
+			text_body,
+			html_body,
+			attempts
+	`, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emails := []models.OutboxEmail{}
+	for rows.Next() {
+		email := models.OutboxEmail{Status: models.OutboxPending}
+
+		err = rows.Scan(&email.ID, &email.Recipient, &email.Subject, &email.TextBody, &email.HTMLBody, &email.Attempts)
+		if err != nil {
+			return nil, err
+		}
+
+		emails = append(emails, email)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return emails, nil
+}
+
+// MarkEmailSent records a delivered email. The bodies are cleared, because
+// they carry live reset, verification and unlock links.
+func (repo *UserRepo) MarkEmailSent(id string) error {
+	_, err := repo.DB.Exec(`
+		UPDATE
+			email_outbox
+		SET
+			status = 'sent',
+			sent_at = CURRENT_TIMESTAMP,
+			last_error = NULL,
+			text_body = '',
+			html_body = ''
+		WHERE
+			id = $1
+	`, id)
+	return err
+}
+
+// MarkEmailFailed records a failed attempt. The email is tried again at
+// nextAttemptAt, or moves to the dead state if dead is set. Dead emails keep
+// their bodies, so an admin can queue them again.
+func (repo *UserRepo) MarkEmailFailed(id, lastError string, nextAttemptAt time.Time, dead bool) error {
+	status := models.OutboxPending
+	if dead {
+		status = models.OutboxDead
+	}
+
+	_, err := repo.DB.Exec(`
+		UPDATE
+			email_outbox
+		SET
+			status = $1,
+			last_error = $2,
+			next_attempt_at = $3
+		WHERE
+			id = $4
+	`, status, lastError, nextAttemptAt, id)
+	return err
+}
+
+// PurgeOutboxEmails deletes sent and dead emails created before the given
+// time and returns how many were deleted.
+func (repo *UserRepo) PurgeOutboxEmails(before time.Time) (int64, error) {
+	res, err := repo.DB.Exec(`
+		DELETE FROM
+			email_outbox
+		WHERE
+			status IN ('sent', 'dead') AND created_at < $1
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// ListOutboxEmails returns the emails with the given status, newest first.
+func (repo *UserRepo) ListOutboxEmails(status string, limit, offset int) ([]models.OutboxEmail, error) {
+	rows, err := repo.DB.Query(`
+		SELECT
+			id,
+			recipient,
+			subject,
+			status,
+			attempts,
+			last_error,
+			next_attempt_at,
+			created_at,
+			sent_at
+		FROM
+			email_outbox
+		WHERE
+			status = $1
+		ORDER BY
+			created_at DESC
+		LIMIT $2
+		OFFSET $3
+	`, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emails := []models.OutboxEmail{}
+	for rows.Next() {
+		var (
+			email         models.OutboxEmail
+			lastError     sql.NullString
+			nextAttemptAt time.Time
+			createdAt     time.Time
+			sentAt        sql.NullTime
+		)
+
+		err = rows.Scan(&email.ID, &email.Recipient, &email.Subject, &email.Status, &email.Attempts, &lastError,
+			&nextAttemptAt, &createdAt, &sentAt)
+		if err != nil {
+			return nil, err
+		}
+
+		email.LastError = lastError.String
+		if email.Status == models.OutboxPending {
+			email.NextAttemptAt = nextAttemptAt.Format("2006-01-02 15:04:05")
+		}
+		email.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+		if sentAt.Valid {
+			email.SentAt = sentAt.Time.Format("2006-01-02 15:04:05")
+		}
+
+		emails = append(emails, email)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return emails, nil
+}
+
+// RetryOutboxEmail puts a dead email back in the queue with a fresh set of
+// attempts. sql.ErrNoRows is returned if there is no dead email with the id.
+func (repo *UserRepo) RetryOutboxEmail(id string) error {
+	res, err := repo.DB.Exec(`
+		UPDATE
+			email_outbox
+		SET
+			status = 'pending',
+			attempts = 0,
+			next_attempt_at = NOW()
+		WHERE
+			id = $1 AND status = 'dead'
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
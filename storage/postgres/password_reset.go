@@ -0,0 +1,191 @@
+package postgres
+
+import (
+	"auth-service/models"
+	"database/sql"
+	"time"
+)
+
+// CreatePasswordReset stores the hash of a new reset token for the user and
+// queues the email with the reset link in the same transaction. Earlier
+// unused tokens of the user stop working.
+func (repo *UserRepo) CreatePasswordReset(userID, tokenHash string, expiresAt time.Time, email *models.OutboxEmail) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		DELETE FROM
+			password_resets
+		WHERE
+			user_id = $1 AND used_at IS NULL
+	`, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO password_resets (
+			user_id,
+			token_hash,
+			expires_at
+		)
+		VALUES (
+			$1,
+			$2,
+			$3
+		)
+	`, userID, tokenHash, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	if err := enqueueEmail(tx, email); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ResetPassword consumes a reset token and sets the new password hash of its
+// user. Every session of the user is revoked in the same transaction.
+// sql.ErrNoRows is returned if the token is unknown, used or expired.
+func (repo *UserRepo) ResetPassword(tokenHash, passwordHash string) (string, error) {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var userID string
+	err = tx.QueryRow(`
+		UPDATE
+			password_resets
+		SET
+			used_at = CURRENT_TIMESTAMP
+		WHERE
+			token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING
+			user_id
+	`, tokenHash).Scan(&userID)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE
+			users
+		SET
+			password_hash = $1,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			id = $2 AND deleted_at = 0
+	`, passwordHash, userID)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE
+			sessions
+		SET
+			revoked_at = CURRENT_TIMESTAMP
+		WHERE
+			user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+
+	return userID, nil
+}
+
+// GetPasswordResetUser returns the user a reset token belongs to without
+// using the token. sql.ErrNoRows is returned if the token is unknown, used or
+// expired.
+func (repo *UserRepo) GetPasswordResetUser(tokenHash string) (*models.LoginResponse, error) {
+	var userID string
+
+	err := repo.DB.QueryRow(`
+		SELECT
+			user_id
+		FROM
+			password_resets
+		WHERE
+			token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+	`, tokenHash).Scan(&userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.GetUserByID(userID)
+}
+
+// ChangePassword sets a new password hash for the user and ends every other
+// session of the user in the same transaction. currentHash must still be the
+// stored hash, so two concurrent changes cannot both win; sql.ErrNoRows is
+// returned otherwise.
+func (repo *UserRepo) ChangePassword(userID, currentHash, newHash, currentSessionID string) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE
+			users
+		SET
+			password_hash = $1,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			id = $2 AND password_hash = $3 AND deleted_at = 0
+	`, newHash, userID, currentHash)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.Exec(`
+		UPDATE
+			sessions
+		SET
+			revoked_at = CURRENT_TIMESTAMP
+		WHERE
+			user_id = $1 AND id <> $2 AND revoked_at IS NULL
+	`, userID, currentSessionID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReplacePasswordHash swaps the password hash of the user for a stronger hash
+// of the same password. Nothing changes if the password was changed since
+// oldHash was read.
+func (repo *UserRepo) ReplacePasswordHash(userID, oldHash, newHash string) error {
+	_, err := repo.DB.Exec(`
+		UPDATE
+			users
+		SET
+			password_hash = $1
+		WHERE
+			id = $2 AND password_hash = $3 AND deleted_at = 0
+	`, newHash, userID, oldHash)
+	return err
+}
@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"auth-service/models"
+)
+
+func (repo *UserRepo) RecordSecurityEvent(event models.SecurityEvent) error {
+	_, err := repo.DB.Exec(`
+		INSERT INTO security_events (
+			user_id,
+			event,
+			email,
+			ip_address,
+			user_agent
+		)
+		VALUES (
+			NULLIF($1, '')::UUID,
+			$2,
+			NULLIF($3, ''),
+			NULLIF($4, ''),
+			NULLIF($5, '')
+		)
+	`, event.UserID, event.Event, event.Email, event.IPAddress, event.UserAgent)
+	return err
+}
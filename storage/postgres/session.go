@@ -0,0 +1,316 @@
+package postgres
+
+import (
+	"auth-service/models"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrRefreshTokenReused is returned when the presented refresh token is no
+// longer the current token of its session, i.e. it has already been rotated.
+// The tokens of a session are kept in refresh_tokens, with the session id as
+// their family_id.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// CreateSession starts a new session for a device. The refresh token hash is
+// set afterwards with SetSessionRefreshToken, because the token itself carries
+// the session id.
+func (repo *UserRepo) CreateSession(session models.Session, expiresAt time.Time) (string, error) {
+	var id string
+
+	err := repo.DB.QueryRow(`
+		INSERT INTO sessions (
+			user_id,
+			user_agent,
+			ip_address,
+			client_id,
+			scope,
+			expires_at
+		)
+		VALUES (
+			$1,
+			$2,
+			$3,
+			NULLIF($4, ''),
+			$5,
+			$6
+		)
+		RETURNING
+			id
+	`, session.UserID, session.UserAgent, session.IPAddress, session.ClientID, session.Scope, expiresAt).Scan(&id)
+
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// IsKnownDevice reports whether the user has signed in from userAgent
+// before. A user without any first-party session yet has no device to
+// compare with, so every device is known to them.
+func (repo *UserRepo) IsKnownDevice(userID, userAgent string) (bool, error) {
+	var known bool
+
+	err := repo.DB.QueryRow(`
+		SELECT
+			NOT EXISTS (
+				SELECT 1 FROM sessions WHERE user_id = $1 AND client_id IS NULL
+			) OR EXISTS (
+				SELECT 1 FROM sessions WHERE user_id = $1 AND client_id IS NULL AND user_agent = $2
+			)
+	`, userID, userAgent).Scan(&known)
+
+	if err != nil {
+		return false, err
+	}
+
+	return known, nil
+}
+
+// SetSessionRefreshToken stores the first refresh token of a session. The
+// session is the family of every token rotated from it.
+func (repo *UserRepo) SetSessionRefreshToken(id, tokenHash string) error {
+	_, err := repo.DB.Exec(`
+		INSERT INTO refresh_tokens (
+			family_id,
+			token_hash,
+			expires_at
+		)
+		SELECT
+			id,
+			$2,
+			expires_at
+		FROM
+			sessions
+		WHERE
+			id = $1 AND revoked_at IS NULL
+	`, id, tokenHash)
+	return err
+}
+
+// RotateRefreshToken marks the current refresh token of a session as used
+// and stores its successor. If oldHash is no longer current (or the session
+// was revoked) nothing is written and ErrRefreshTokenReused is returned.
+func (repo *UserRepo) RotateRefreshToken(id, oldHash, newHash string, expiresAt time.Time) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE
+			refresh_tokens
+		SET
+			used_at = NOW()
+		WHERE
+			family_id = $1 AND token_hash = $2 AND used_at IS NULL AND revoked_at IS NULL
+			AND EXISTS (
+				SELECT 1 FROM sessions WHERE id = $1 AND revoked_at IS NULL
+			)
+	`, id, oldHash)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRefreshTokenReused
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO refresh_tokens (
+			family_id,
+			token_hash,
+			expires_at
+		)
+		VALUES (
+			$1,
+			$2,
+			$3
+		)
+	`, id, newHash, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE
+			sessions
+		SET
+			expires_at = $1,
+			last_used_at = CURRENT_TIMESTAMP
+		WHERE
+			id = $2
+	`, expiresAt, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *UserRepo) GetSession(id string) (*models.Session, error) {
+	var (
+		session    models.Session
+		userAgent  sql.NullString
+		ipAddress  sql.NullString
+		clientID   sql.NullString
+		createdAt  time.Time
+		lastUsedAt time.Time
+		expiresAt  time.Time
+		revokedAt  sql.NullTime
+	)
+
+	err := repo.DB.QueryRow(`
+		SELECT
+			id,
+			user_id,
+			user_agent,
+			ip_address,
+			client_id,
+			scope,
+			COALESCE((
+				SELECT
+					token_hash
+				FROM
+					refresh_tokens
+				WHERE
+					family_id = sessions.id AND used_at IS NULL AND revoked_at IS NULL
+				ORDER BY
+					created_at DESC
+				LIMIT 1
+			), ''),
+			created_at,
+			last_used_at,
+			expires_at,
+			revoked_at
+		FROM
+			sessions
+		WHERE
+			id = $1
+	`, id).Scan(&session.ID, &session.UserID, &userAgent, &ipAddress, &clientID, &session.Scope, &session.RefreshTokenHash,
+		&createdAt, &lastUsedAt, &expiresAt, &revokedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	session.UserAgent = userAgent.String
+	session.IPAddress = ipAddress.String
+	session.ClientID = clientID.String
+	session.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+	session.LastUsedAt = lastUsedAt.Format("2006-01-02 15:04:05")
+	session.ExpiresAt = expiresAt.Format("2006-01-02 15:04:05")
+	session.Revoked = revokedAt.Valid || expiresAt.Before(time.Now())
+
+	return &session, nil
+}
+
+func (repo *UserRepo) GetActiveSessions(userID string) ([]models.Session, error) {
+	rows, err := repo.DB.Query(`
+		SELECT
+			id,
+			user_id,
+			user_agent,
+			ip_address,
+			client_id,
+			created_at,
+			last_used_at,
+			expires_at
+		FROM
+			sessions
+		WHERE
+			user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY
+			last_used_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var (
+			session    models.Session
+			userAgent  sql.NullString
+			ipAddress  sql.NullString
+			clientID   sql.NullString
+			createdAt  time.Time
+			lastUsedAt time.Time
+			expiresAt  time.Time
+		)
+
+		err = rows.Scan(&session.ID, &session.UserID, &userAgent, &ipAddress, &clientID, &createdAt, &lastUsedAt, &expiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		session.UserAgent = userAgent.String
+		session.IPAddress = ipAddress.String
+		session.ClientID = clientID.String
+		session.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+		session.LastUsedAt = lastUsedAt.Format("2006-01-02 15:04:05")
+		session.ExpiresAt = expiresAt.Format("2006-01-02 15:04:05")
+
+		sessions = append(sessions, session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession ends a single session of the user. sql.ErrNoRows is returned
+// if the session does not exist, belongs to someone else or is already revoked.
+func (repo *UserRepo) RevokeSession(id, userID string) error {
+	res, err := repo.DB.Exec(`
+		UPDATE
+			sessions
+		SET
+			revoked_at = CURRENT_TIMESTAMP
+		WHERE
+			id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions ends every session of the user except currentID and
+// returns how many sessions were revoked.
+func (repo *UserRepo) RevokeOtherSessions(userID, currentID string) (int64, error) {
+	res, err := repo.DB.Exec(`
+		UPDATE
+			sessions
+		SET
+			revoked_at = CURRENT_TIMESTAMP
+		WHERE
+			user_id = $1 AND id <> $2 AND revoked_at IS NULL
+	`, userID, currentID)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
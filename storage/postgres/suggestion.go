@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	pb "auth-service/generated/user"
+)
+
+// Weights of the parts of a suggestion score.
+const (
+	// Each user followed by someone the user follows
+	mutualFollowWeight = 1.0
+	// Scaled by how close the two users' countries_visited counts are, from
+	// 0 to 1. This is not an overlap of destinations: only the count is
+	// stored, no service keeps which countries a user visited.
+	countriesWeight = 0.5
+	// Scaled from 1 for users active now towards 0 for long inactive ones
+	activityWeight = 0.5
+	// activityDays is how fast the activity part fades, in days.
+	activityDays = 30
+)
+
+// SuggestUsers ranks users userID may want to follow: friends of friends
+// first, then users who visited about as many countries and recently active
+// users. Shared destinations cannot be compared, since only the number of
+// visited countries is known. Users already followed or requested, blocked
+// in either direction, muted by userID and deleted users are left out.
+func (repo *UserRepo) SuggestUsers(userID string, limit int) ([]*pb.SuggestedUser, error) {
+	rows, err := repo.DB.Query(`
+		WITH mutual AS (
+			SELECT
+				f2.following_id AS user_id,
+				COUNT(*) AS follows
+			FROM
+				followers f1
+			INNER JOIN
+				followers f2 ON f2.follower_id = f1.following_id
+			WHERE
+				f1.follower_id = $1 AND f2.following_id <> $1
+			GROUP BY
+				f2.following_id
+		),
+		me AS (
+			SELECT
+				COALESCE(countries_visited, 0) AS countries_visited
+			FROM
+				users
+			WHERE
+				id = $1
+		),
+		candidates AS (
+			SELECT
+				u.id,
+				u.username,
+				u.full_name,
+				-- Yopiq akkauntlarning mamlakatlari ko'rsatilmaydi
+				CASE WHEN u.is_private THEN 0 ELSE COALESCE(u.countries_visited, 0) END AS countries_visited,
+				COALESCE(m.follows, 0) AS mutual_follows,
+				u.updated_at
+			FROM
+				users u
+			LEFT JOIN
+				mutual m ON m.user_id = u.id
+			WHERE
+				u.deleted_at = 0 AND u.id <> $1 AND
+				NOT EXISTS (SELECT 1 FROM followers f WHERE f.follower_id = $1 AND f.following_id = u.id) AND
+				NOT EXISTS (SELECT 1 FROM follow_requests r WHERE r.requester_id = $1 AND r.target_id = u.id) AND
+				NOT EXISTS (
+					SELECT 1 FROM blocks b
+					WHERE (b.blocker_id = $1 AND b.blocked_id = u.id) OR
+						(b.blocker_id = u.id AND b.blocked_id = $1 AND b.kind = 'block')
+				)
+		)
+		SELECT
+			c.id,
+			c.username,
+			c.full_name,
+			c.countries_visited,
+			c.mutual_follows,
+			$3 * c.mutual_follows +
+			$4 * CASE
+				WHEN GREATEST(c.countries_visited, me.countries_visited) = 0 THEN 0
+				ELSE LEAST(c.countries_visited, me.countries_visited)::FLOAT / GREATEST(c.countries_visited, me.countries_visited)
+			END +
+			$5 * EXP(-EXTRACT(EPOCH FROM NOW() - c.updated_at) / ($6 * 86400)) AS score
+		FROM
+			candidates c, me
+		ORDER BY
+			score DESC,
+			c.id
+		LIMIT $2
+	`, userID, limit, mutualFollowWeight, countriesWeight, activityWeight, activityDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*pb.SuggestedUser{}
+	for rows.Next() {
+		var user pb.SuggestedUser
+		err := rows.Scan(&user.Id, &user.Username, &user.FullName, &user.CountriesVisited, &user.MutualFollows, &user.Score)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, &user)
+	}
+
+	return users, rows.Err()
+}
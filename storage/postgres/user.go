@@ -17,12 +17,22 @@ func NewUserRepo(db *sql.DB) *UserRepo {
 	}
 }
 
-func (repo *UserRepo) CreateUser(user models.RegisterRequest) (*models.RegisterResponse, error) {
+// CreateUser inserts a new user. If welcome is not nil, the email it builds
+// for the new user is queued in the same transaction, so the user is not
+// created without it.
+func (repo *UserRepo) CreateUser(user models.RegisterRequest, welcome func(*models.RegisterResponse) (*models.OutboxEmail, error)) (*models.RegisterResponse, error) {
 	var (
 		userResp  models.RegisterResponse
 		createdAt time.Time
 	)
-	err := repo.DB.QueryRow(`
+
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
 		INSERT INTO users (
 			username,
 			email,
@@ -50,6 +60,20 @@ func (repo *UserRepo) CreateUser(user models.RegisterRequest) (*models.RegisterR
 
 	userResp.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
 
+	if welcome != nil {
+		email, err := welcome(&userResp)
+		if err != nil {
+			return nil, err
+		}
+		if err := enqueueEmail(tx, email); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return &userResp, nil
 }
 
@@ -61,12 +85,16 @@ func (repo *UserRepo) GetUserByEmail(email string) (*models.LoginResponse, error
 			id,
 			username,
 			email,
-			password_hash
+			password_hash,
+			role,
+			totp_enabled_at IS NOT NULL,
+			email_verified_at IS NOT NULL
 		FROM
 			users
 		WHERE
 			deleted_at = 0 AND email = $1
-	`, email).Scan(&userResp.ID, &userResp.Username, &userResp.Email, &userResp.Password)
+	`, email).Scan(&userResp.ID, &userResp.Username, &userResp.Email, &userResp.Password, &userResp.Role,
+		&userResp.TwoFactorEnabled, &userResp.EmailVerified)
 
 	if err != nil {
 		return nil, err
@@ -75,25 +103,30 @@ func (repo *UserRepo) GetUserByEmail(email string) (*models.LoginResponse, error
 	return &userResp, nil
 }
 
-func (repo *UserRepo) UpdatePassword(resetPassword models.UpdatePassword) (*models.Success, error) {
-	_, err := repo.DB.Exec(`
-		UPDATE 
-			users 
-		SET 
-			password_hash = $1 
-		WHERE 
-			id = $2 and deleted_at = 0
-	`, resetPassword.NewPassword, resetPassword.ID)
+func (repo *UserRepo) GetUserByID(id string) (*models.LoginResponse, error) {
+	var userResp models.LoginResponse
+
+	err := repo.DB.QueryRow(`
+		SELECT
+			id,
+			username,
+			email,
+			password_hash,
+			role,
+			totp_enabled_at IS NOT NULL,
+			email_verified_at IS NOT NULL
+		FROM
+			users
+		WHERE
+			deleted_at = 0 AND id = $1
+	`, id).Scan(&userResp.ID, &userResp.Username, &userResp.Email, &userResp.Password, &userResp.Role,
+		&userResp.TwoFactorEnabled, &userResp.EmailVerified)
 
 	if err != nil {
-		return &models.Success{
-			Message: "Error in updated password",
-		}, err
+		return nil, err
 	}
 
-	return &models.Success{
-		Message: "Reset password successfully",
-	}, nil
+	return &userResp, nil
 }
 
 func (repo *UserRepo) EmailExists(email string) (bool, error) {
@@ -114,19 +147,23 @@ func (repo *UserRepo) EmailExists(email string) (bool, error) {
 	return exists, nil
 }
 
-func (repo *UserRepo) GetUserInfo(id string) (*pb.UserInfoResponse, error) {
+// GetUserInfo returns the basic info of user id as viewerID sees it.
+// sql.ErrNoRows is returned if the user blocked viewerID.
+func (repo *UserRepo) GetUserInfo(id, viewerID string) (*pb.UserInfoResponse, error) {
 	var info pb.UserInfoResponse
 
 	err := repo.DB.QueryRow(`
 		SELECT
 			id,
 			username,
-			full_name
+			full_name,
+			email_verified_at IS NOT NULL
 		FROM
-			users
+			users u
 		WHERE
-			deleted_at = 0 and id = $1
-	`, id).Scan(&info.Id, &info.Username, &info.FullName)
+			deleted_at = 0 and id = $1 AND
+			NOT EXISTS (SELECT 1 FROM blocks b WHERE b.blocker_id = u.id AND b.blocked_id = $2 AND b.kind = 'block')
+	`, id, viewerParam(viewerID)).Scan(&info.Id, &info.Username, &info.FullName, &info.EmailVerified)
 
 	if err != nil {
 		return nil, err
@@ -135,7 +172,11 @@ func (repo *UserRepo) GetUserInfo(id string) (*pb.UserInfoResponse, error) {
 	return &info, nil
 }
 
-func (repo *UserRepo) GetUserProfile(id string) (*pb.GetProfileResponse, error) {
+// GetUserProfile returns the profile of user id as viewerID sees it.
+// sql.ErrNoRows is returned if the user blocked viewerID, as if the user did
+// not exist. Private users show only their name to viewers who do not follow
+// them, the response is marked restricted then.
+func (repo *UserRepo) GetUserProfile(id, viewerID string) (*pb.GetProfileResponse, error) {
 	var (
 		profile   pb.GetProfileResponse
 		bio       sql.NullString
@@ -152,17 +193,38 @@ func (repo *UserRepo) GetUserProfile(id string) (*pb.GetProfileResponse, error)
 			bio,
 			countries_visited,
 			created_at,
-			updated_at
+			updated_at,
+			email_verified_at IS NOT NULL,
+			followers_count,
+			following_count,
+			is_private,
+			COALESCE(is_private AND $2::UUID IS NOT NULL AND u.id <> $2 AND
+				NOT EXISTS (SELECT 1 FROM followers f WHERE f.follower_id = $2 AND f.following_id = u.id), FALSE)
 		FROM
-			users
+			users u
 		WHERE
-			id = $1 AND deleted_at = 0
-	`, id).Scan(&profile.Id, &profile.Username, &profile.Email, &profile.FullName, &bio, &profile.CountriesVisited, &createdAt, &updatedAt)
+			id = $1 AND deleted_at = 0 AND
+			NOT EXISTS (SELECT 1 FROM blocks b WHERE b.blocker_id = u.id AND b.blocked_id = $2 AND b.kind = 'block')
+	`, id, viewerParam(viewerID)).Scan(&profile.Id, &profile.Username, &profile.Email, &profile.FullName, &bio, &profile.CountriesVisited, &createdAt, &updatedAt,
+		&profile.EmailVerified, &profile.FollowersCount, &profile.FollowingCount, &profile.IsPrivate, &profile.Restricted)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if profile.Restricted {
+		return &pb.GetProfileResponse{
+			Id:             profile.Id,
+			Username:       profile.Username,
+			FullName:       profile.FullName,
+			CreatedAt:      createdAt.Format("2006-01-02 15:04:05"),
+			IsPrivate:      true,
+			Restricted:     true,
+			FollowersCount: profile.FollowersCount,
+			FollowingCount: profile.FollowingCount,
+		}, nil
+	}
+
 	if !bio.Valid {
 		profile.Bio = "No Bio"
 	}
@@ -210,7 +272,9 @@ func (repo *UserRepo) UpdateUserProfile(req *pb.UpdateProfileRequest) (*pb.Updat
 	return &profile, nil
 }
 
-func (repo *UserRepo) GetUsers(req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+// GetUsers lists users by username. Users who blocked viewerID are left out,
+// and private users viewerID does not follow show no visited countries.
+func (repo *UserRepo) GetUsers(req *pb.ListUsersRequest, viewerID string) (*pb.ListUsersResponse, error) {
 	offset := (req.Page - 1) * req.Limit
 
 	rows, err := repo.DB.Query(`
@@ -218,16 +282,24 @@ func (repo *UserRepo) GetUsers(req *pb.ListUsersRequest) (*pb.ListUsersResponse,
 			id, 
 			username, 
 			full_name, 
-			countries_visited
+			CASE
+				WHEN is_private AND $3::UUID IS NOT NULL AND u.id <> $3 AND
+					NOT EXISTS (SELECT 1 FROM followers f WHERE f.follower_id = $3 AND f.following_id = u.id)
+				THEN 0
+				ELSE countries_visited
+			END,
+			followers_count,
+			following_count
         FROM 
-			users
+			users u
 		WHERE 
-			deleted_at = 0
+			deleted_at = 0 AND
+			NOT EXISTS (SELECT 1 FROM blocks b WHERE b.blocker_id = u.id AND b.blocked_id = $3 AND b.kind = 'block')
         ORDER BY 
 			username
         LIMIT $1 
 		OFFSET $2
-	`, req.Limit, offset)
+	`, req.Limit, offset, viewerParam(viewerID))
 	if err != nil {
 		return nil, err
 	}
@@ -236,7 +308,8 @@ func (repo *UserRepo) GetUsers(req *pb.ListUsersRequest) (*pb.ListUsersResponse,
 	var users []*pb.User
 	for rows.Next() {
 		var user pb.User
-		if err := rows.Scan(&user.Id, &user.Username, &user.FullName, &user.CountriesVisited); err != nil {
+		if err := rows.Scan(&user.Id, &user.Username, &user.FullName, &user.CountriesVisited, &user.FollowersCount,
+			&user.FollowingCount); err != nil {
 			return nil, err
 		}
 		users = append(users, &user)
@@ -247,10 +320,11 @@ func (repo *UserRepo) GetUsers(req *pb.ListUsersRequest) (*pb.ListUsersResponse,
 		SELECT 
 			COUNT(*) 
 		FROM 
-			users
+			users u
 		WHERE
-			deleted_at = 0
-	`).Scan(&total)
+			deleted_at = 0 AND
+			NOT EXISTS (SELECT 1 FROM blocks b WHERE b.blocker_id = u.id AND b.blocked_id = $1 AND b.kind = 'block')
+	`, viewerParam(viewerID)).Scan(&total)
 
 	if err != nil {
 		return nil, err
@@ -266,8 +340,16 @@ func (repo *UserRepo) GetUsers(req *pb.ListUsersRequest) (*pb.ListUsersResponse,
 	return resp, nil
 }
 
-func (repo *UserRepo) DeleteUser(id string) (*pb.DeleteUserResponse, error) {
-	res, err := repo.DB.Exec(`
+// DeleteUser soft deletes a user and removes their follows and follow
+// requests. farewell, if not nil, is queued in the same transaction.
+func (repo *UserRepo) DeleteUser(id string, farewell *models.OutboxEmail) (*pb.DeleteUserResponse, error) {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
         UPDATE
             users
         SET
@@ -290,111 +372,99 @@ func (repo *UserRepo) DeleteUser(id string) (*pb.DeleteUserResponse, error) {
 		return nil, err
 	}
 
-	return &pb.DeleteUserResponse{
-		Message: "User successfully deleted",
-	}, nil
-}
-
-func (repo *UserRepo) FollowingUser(req *pb.FollowUserRequest) (*pb.FollowUserResponse, error) {
-	var follower pb.FollowUserResponse
-
-	err := repo.DB.QueryRow(`
-		INSERT INTO followers (
-			follower_id,
-			following_id
-		)
-		VALUES (
-			$1,
-			$2
-		)
-		RETURNING
-			follower_id,
-			following_id,
-			followed_at
-	`, req.FollowerId, req.FollowingId).Scan(&follower.FollowerId, &follower.FollowingId, &follower.FollowingAt)
-
+	// Follow va so'rovlar o'chiriladi, boshqa userlarning sonlari kamayadi
+	_, err = removeFollows(tx, "follower_id = $1 OR following_id = $1", id)
 	if err != nil {
 		return nil, err
 	}
-	return &follower, nil
-}
 
-func (repo *UserRepo) GetFollowers(req *pb.ListFollowersRequest) (*pb.ListFollowersResponse, error) {
-	var followers []*pb.Follower
-	offset := (req.Page - 1) * req.Limit
-	rows, err := repo.DB.Query(`
-		SELECT
-			u.id,
-			username,
-			full_name
-		FROM
-			users u
-		INNER JOIN
-			followers f ON u.id = f.follower_id
+	_, err = tx.Exec(`
+		DELETE FROM
+			follow_requests
 		WHERE
-			f.following_id = $1 and deleted_at = 0
-		OFFSET $2
-		LIMIT $3
-	`, req.UserId, offset, req.Limit)
-
+			requester_id = $1 OR target_id = $1
+	`, id)
 	if err != nil {
 		return nil, err
 	}
 
-	for rows.Next() {
-		var follower pb.Follower
-
-		err = rows.Scan(&follower.Id, &follower.Username, &follower.FullName)
-		if err != nil {
+	if farewell != nil {
+		if err := enqueueEmail(tx, farewell); err != nil {
 			return nil, err
 		}
-
-		followers = append(followers, &follower)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 
-	var total int32
-	err = repo.DB.QueryRow(`
+	return &pb.DeleteUserResponse{
+		Message: "User successfully deleted",
+	}, nil
+}
+
+// GetUserActivity returns the activity of user id as viewerID sees it.
+// Private users hide their visited countries and last activity from viewers
+// who do not follow them. sql.ErrNoRows is returned if the user blocked
+// viewerID.
+func (repo *UserRepo) GetUserActivity(id, viewerID string) (*pb.GetUserActivityResponse, error) {
+	var (
+		resp       pb.GetUserActivityResponse
+		lastActive sql.NullTime
+	)
+
+	err := repo.DB.QueryRow(`
+		WITH activity AS (
+			SELECT
+				id,
+				countries_visited,
+				updated_at,
+				COALESCE(is_private AND $2::UUID IS NOT NULL AND u.id <> $2 AND
+					NOT EXISTS (SELECT 1 FROM followers f WHERE f.follower_id = $2 AND f.following_id = u.id), FALSE) AS restricted
+			FROM
+				users u
+			WHERE
+				deleted_at = 0 and id = $1 AND
+				NOT EXISTS (SELECT 1 FROM blocks b WHERE b.blocker_id = u.id AND b.blocked_id = $2 AND b.kind = 'block')
+		)
 		SELECT
-			COUNT(*)
+			id,
+			CASE WHEN restricted THEN 0 ELSE countries_visited END,
+			CASE WHEN restricted THEN NULL ELSE updated_at END
 		FROM
-			users u
-		INNER JOIN
-			followers f ON u.id = f.follower_id
-		WHERE
-			f.following_id = $1 and u.deleted_at = 0
-	`, req.UserId).Scan(&total)
+			activity
+	`, id, viewerParam(viewerID)).Scan(&resp.UserId, &resp.CountriesVisited, &lastActive)
 	if err != nil {
 		return nil, err
 	}
 
-	
-	resp := &pb.ListFollowersResponse{
-		Followers: followers,
-		Total:     total,
-		Page:      req.Page,
-		Limit:     req.Limit,
+	if lastActive.Valid {
+		resp.LastActive = lastActive.Time.Format(time.RFC3339Nano)
 	}
 
-	return resp, nil
+	return &resp, nil
 }
 
-func (repo *UserRepo) GetUserActivity(id string) (*pb.GetUserActivityResponse, error) {
-	var resp pb.GetUserActivityResponse
-
-	err := repo.DB.QueryRow(`
-		SELECT
-			id,
-			countries_visited,
-			updated_at
-		FROM
+// MarkEmailVerified sets email_verified_at if the user still has the address
+// the verification was sent to. It reports whether the email was verified by
+// this call.
+func (repo *UserRepo) MarkEmailVerified(id, email string) (bool, error) {
+	res, err := repo.DB.Exec(`
+		UPDATE
 			users
+		SET
+			email_verified_at = CURRENT_TIMESTAMP
 		WHERE
-			deleted_at = 0 and id = $1
-	`, id).Scan(&resp.UserId, &resp.CountriesVisited, &resp.LastActive)
+			id = $1 AND email = $2 AND deleted_at = 0 AND email_verified_at IS NULL
+	`, id, email)
+	if err != nil {
+		return false, err
+	}
 
-	return &resp, err
-}
\ No newline at end of file
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	pb "auth-service/generated/user"
 	"auth-service/models"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -35,7 +36,7 @@ func TestCreateUser(t *testing.T) {
 		Email:    "sanjarbek2007@gmail.com",
 		Password: "sqwerty007",
 		FullName: "Sanjarbek",
-	})
+	}, nil)
 
 	if err != nil {
 		t.Fatal(err)
@@ -73,36 +74,12 @@ func TestGetUserByEmail(t *testing.T) {
 		Username: "diyorbek0321",
 		Email:    "diyorbeknematov@gmail.com",
 		Password: "$2a$10$dvdDaTR3ZBb5x5MMZf1xPOk3Nb5zbx35D8444y0OViFRsgQawEcu.",
+		Role:     "user",
 	}
 
 	assert.Equal(t, getResp, response)
 }
 
-func TestUpdatePassword(t *testing.T) {
-	db, err := ConnectDB()
-	if err != nil {
-		t.Fatalf("Failed to connect to database: %v", err)
-	}
-	defer db.Close()
-
-	userRepo := NewUserRepo(db)
-
-	resp, err := userRepo.UpdatePassword(models.UpdatePassword{
-		ID:          "9b0cf2c8-308c-4896-a737-511bff1bb991",
-		NewPassword: "anvarnarzdeveloper",
-	})
-
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	response := &models.Success{
-		Message: "Reset password successfully",
-	}
-
-	assert.Equal(t, resp, response)
-}
-
 func TestEmailExists(t *testing.T) {
 	db, err := ConnectDB()
 	if err != nil {
@@ -130,7 +107,7 @@ func TestGetUserInfo(t *testing.T) {
 
 	userRepo := NewUserRepo(db)
 
-	resp, err := userRepo.GetUserInfo("975799c4-bd72-43c8-b0c5-93bd9461e033")
+	resp, err := userRepo.GetUserInfo("975799c4-bd72-43c8-b0c5-93bd9461e033", "")
 
 	if err != nil {
 		t.Fatal(err)
@@ -154,7 +131,7 @@ func TestGetUserProfile(t *testing.T) {
 
 	userRepo := NewUserRepo(db)
 
-	resp, err := userRepo.GetUserProfile("975799c4-bd72-43c8-b0c5-93bd9461e033")
+	resp, err := userRepo.GetUserProfile("975799c4-bd72-43c8-b0c5-93bd9461e033", "")
 
 	if err != nil {
 		t.Fatal(err)
@@ -219,7 +196,7 @@ func TestGetUsers(t *testing.T) {
 	resp, err := userRepo.GetUsers(&pb.ListUsersRequest{
 		Page:  1,
 		Limit: 2,
-	})
+	}, "")
 
 	if err != nil {
 		t.Fatal(err)
@@ -259,7 +236,7 @@ func TestDeleteUser(t *testing.T) {
 
 	userRepo := NewUserRepo(db)
 
-	resp, err := userRepo.DeleteUser("e1b9af75-931d-4d3b-acd7-a00e2571fa92")
+	resp, err := userRepo.DeleteUser("e1b9af75-931d-4d3b-acd7-a00e2571fa92", nil)
 
 	if err != nil {
 		t.Fatal(err)
@@ -311,7 +288,7 @@ func TestGetFollowers(t *testing.T) {
 		UserId: "9b0cf2c8-308c-4896-a737-511bff1bb991",
 		Page:   1,
 		Limit:  1,
-	})
+	}, "")
 	if err != nil {
 		t.Fatalf("Error getting followers: %v", err)
 	}
@@ -333,6 +310,297 @@ func TestGetFollowers(t *testing.T) {
 	assert.Equal(t, resp, expected)
 }
 
+func TestFollowingUserTwice(t *testing.T) {
+	db, err := ConnectDB()
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+	req := &pb.FollowUserRequest{
+		FollowingId: "9b0cf2c8-308c-4896-a737-511bff1bb991",
+		FollowerId:  "975799c4-bd72-43c8-b0c5-93bd9461e033",
+	}
+
+	first, err := repo.FollowingUser(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := repo.FollowingUser(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, first, second)
+}
+
+func TestGetRelationship(t *testing.T) {
+	db, err := ConnectDB()
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+
+	resp, err := repo.GetRelationship(&pb.GetRelationshipRequest{
+		UserId:  "975799c4-bd72-43c8-b0c5-93bd9461e033",
+		OtherId: "9b0cf2c8-308c-4896-a737-511bff1bb991",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, resp.Following)
+	assert.Equal(t, resp.Following && resp.FollowedBy, resp.Mutual)
+}
+
+func TestCheckFollows(t *testing.T) {
+	db, err := ConnectDB()
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+
+	resp, err := repo.CheckFollows(&pb.CheckFollowsRequest{
+		FollowerId: "975799c4-bd72-43c8-b0c5-93bd9461e033",
+		UserIds:    []string{"9b0cf2c8-308c-4896-a737-511bff1bb991", "e1b9af75-931d-4d3b-acd7-a00e2571fa92"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, resp.FollowingIds, "9b0cf2c8-308c-4896-a737-511bff1bb991")
+}
+
+func TestUnfollowUser(t *testing.T) {
+	db, err := ConnectDB()
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+	req := &pb.UnfollowUserRequest{
+		FollowingId: "9b0cf2c8-308c-4896-a737-511bff1bb991",
+		FollowerId:  "975799c4-bd72-43c8-b0c5-93bd9461e033",
+	}
+
+	resp, err := repo.UnfollowUser(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, resp.Unfollowed)
+
+	// Ikkinchi marta hech narsa o'chirilmaydi
+	resp, err = repo.UnfollowUser(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, resp.Unfollowed)
+}
+
+func TestBlockUser(t *testing.T) {
+	db, err := ConnectDB()
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+	blocker := "9b0cf2c8-308c-4896-a737-511bff1bb991"
+	blocked := "975799c4-bd72-43c8-b0c5-93bd9461e033"
+
+	_, err = repo.FollowingUser(&pb.FollowUserRequest{FollowerId: blocked, FollowingId: blocker})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = repo.BlockUser(&pb.BlockUserRequest{BlockerId: blocker, BlockedId: blocked})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Follow o'chirilgan va qayta follow qilib bo'lmaydi
+	rel, err := repo.GetRelationship(&pb.GetRelationshipRequest{UserId: blocked, OtherId: blocker})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, rel.Following)
+
+	_, err = repo.FollowingUser(&pb.FollowUserRequest{FollowerId: blocked, FollowingId: blocker})
+	assert.ErrorIs(t, err, ErrBlocked)
+
+	_, err = repo.GetUserProfile(blocker, blocked)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.GetUserInfo(blocker, blocked)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.GetUserActivity(blocker, blocked)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	resp, err := repo.IsBlocked(&pb.IsBlockedRequest{UserId: blocked, OtherId: blocker})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, resp.Blocked)
+	assert.True(t, resp.OtherBlockedUser)
+	assert.False(t, resp.UserBlockedOther)
+
+	unblock, err := repo.UnblockUser(&pb.UnblockUserRequest{BlockerId: blocker, BlockedId: blocked})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, unblock.Unblocked)
+}
+
+func TestPrivateAccount(t *testing.T) {
+	db, err := ConnectDB()
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+	private := "9b0cf2c8-308c-4896-a737-511bff1bb991"
+	requester := "975799c4-bd72-43c8-b0c5-93bd9461e033"
+
+	_, _, err = repo.SetAccountPrivacy(&pb.SetAccountPrivacyRequest{UserId: private, IsPrivate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.SetAccountPrivacy(&pb.SetAccountPrivacyRequest{UserId: private, IsPrivate: false})
+
+	_, err = repo.UnfollowUser(&pb.UnfollowUserRequest{FollowerId: requester, FollowingId: private})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	follow, err := repo.FollowingUser(&pb.FollowUserRequest{FollowerId: requester, FollowingId: private})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, follow.Pending)
+
+	profile, err := repo.GetUserProfile(private, requester)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, profile.Restricted)
+	assert.Empty(t, profile.Email)
+	assert.Empty(t, profile.Bio)
+
+	activity, err := repo.GetUserActivity(private, requester)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Zero(t, activity.CountriesVisited)
+	assert.Empty(t, activity.LastActive)
+
+	requests, err := repo.GetFollowRequests(&pb.ListFollowRequestsRequest{UserId: private, Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int32(1), requests.Total)
+	assert.Equal(t, requester, requests.Requests[0].RequesterId)
+
+	_, err = repo.ApproveFollowRequest(&pb.ApproveFollowRequestRequest{UserId: private, RequesterId: requester})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err = repo.GetUserProfile(private, requester)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, profile.Restricted)
+	assert.True(t, profile.IsPrivate)
+
+	activity, err = repo.GetUserActivity(private, requester)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, activity.LastActive)
+}
+
+func TestSuggestUsers(t *testing.T) {
+	db, err := ConnectDB()
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+	userID := "975799c4-bd72-43c8-b0c5-93bd9461e033"
+
+	users, err := repo.SuggestUsers(userID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []string{}
+	for i, user := range users {
+		ids = append(ids, user.Id)
+		if i > 0 {
+			assert.GreaterOrEqual(t, users[i-1].Score, user.Score)
+		}
+	}
+	assert.NotContains(t, ids, userID)
+
+	check, err := repo.CheckFollows(&pb.CheckFollowsRequest{FollowerId: userID, UserIds: ids})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, check.FollowingIds)
+}
+
+func TestFollowCounts(t *testing.T) {
+	db, err := ConnectDB()
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+	follower := "975799c4-bd72-43c8-b0c5-93bd9461e033"
+	following := "9b0cf2c8-308c-4896-a737-511bff1bb991"
+
+	_, err = repo.UnfollowUser(&pb.UnfollowUserRequest{FollowerId: follower, FollowingId: following})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := repo.GetUserProfile(following, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ikki marta follow qilish sonni bir marta oshiradi
+	for i := 0; i < 2; i++ {
+		_, err = repo.FollowingUser(&pb.FollowUserRequest{FollowerId: follower, FollowingId: following})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	after, err := repo.GetUserProfile(following, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, before.FollowersCount+1, after.FollowersCount)
+
+	drift, err := repo.ReconcileFollowCounts(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range drift {
+		assert.NotContains(t, []string{follower, following}, d.UserID)
+	}
+}
+
 func TestGetUserActivity(t *testing.T) {
 	db, err := ConnectDB()
 	if err != nil {
@@ -342,7 +610,7 @@ func TestGetUserActivity(t *testing.T) {
 
 	repo := NewUserRepo(db)
 	// Call the method
-	resp, err := repo.GetUserActivity("975799c4-bd72-43c8-b0c5-93bd9461e033")
+	resp, err := repo.GetUserActivity("975799c4-bd72-43c8-b0c5-93bd9461e033", "")
 	if err != nil {
 		t.Fatalf("Error getting user activity: %v", err)
 	}
@@ -40,4 +40,166 @@ func (rdb *RedisClient) IsTokenBlacklisted(token string) (bool, error) {
         return false, err
     }
     return val == "blacklisted", nil
-}
\ No newline at end of file
+}
+
+// SaveAuthCode stores an OAuth authorization code under the hash of the code.
+func (rdb *RedisClient) SaveAuthCode(codeHash string, data []byte, expirationTime time.Duration) error {
+	return rdb.R.Set(ctx, "oauth_code:"+codeHash, data, expirationTime).Err()
+}
+
+// TakeAuthCode returns and deletes an authorization code in one step, so a
+// code can be redeemed only once. found is false for unknown or expired codes.
+func (rdb *RedisClient) TakeAuthCode(codeHash string) (data []byte, found bool, err error) {
+	data, err = rdb.R.GetDel(ctx, "oauth_code:"+codeHash).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// CountAttempt increments the counter under key and returns the new value.
+// The counter expires window after the first attempt.
+func (rdb *RedisClient) CountAttempt(key string, window time.Duration) (int64, error) {
+	count, err := rdb.R.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := rdb.R.Expire(ctx, key, window).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// MarkOnce sets key and reports whether it was not set before, so that a
+// one-time value (e.g. a TOTP code) is accepted only once.
+func (rdb *RedisClient) MarkOnce(key string, expirationTime time.Duration) (bool, error) {
+	return rdb.R.SetNX(ctx, key, 1, expirationTime).Result()
+}
+
+// Block sets key for d. BlockedFor reports the time left on it.
+func (rdb *RedisClient) Block(key string, d time.Duration) error {
+	return rdb.R.Set(ctx, key, 1, d).Err()
+}
+
+// BlockedFor returns how long key stays set, or 0 if it is not set.
+func (rdb *RedisClient) BlockedFor(key string) (time.Duration, error) {
+	ttl, err := rdb.R.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// ClearAttempts deletes attempt counters and blocks.
+func (rdb *RedisClient) ClearAttempts(keys ...string) error {
+	return rdb.R.Del(ctx, keys...).Err()
+}
+
+// SaveUnlockToken stores the email of a locked account under the hash of the
+// token sent in the unlock email.
+func (rdb *RedisClient) SaveUnlockToken(tokenHash, email string, expirationTime time.Duration) error {
+	return rdb.R.Set(ctx, "login_unlock:"+tokenHash, email, expirationTime).Err()
+}
+
+// TakeUnlockToken returns and deletes the email stored for an unlock token,
+// so a token works once. found is false for unknown or expired tokens.
+func (rdb *RedisClient) TakeUnlockToken(tokenHash string) (email string, found bool, err error) {
+	email, err = rdb.R.GetDel(ctx, "login_unlock:"+tokenHash).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return email, true, nil
+}
+
+func suggestionsKey(userID string) string { return "suggestions:" + userID }
+
+// SaveSuggestions caches the encoded follow suggestions of a user.
+func (rdb *RedisClient) SaveSuggestions(userID string, data []byte, expirationTime time.Duration) error {
+	return rdb.R.Set(ctx, suggestionsKey(userID), data, expirationTime).Err()
+}
+
+// GetSuggestions returns the cached suggestions of a user. found is false if
+// there are none or they expired.
+func (rdb *RedisClient) GetSuggestions(userID string) (data []byte, found bool, err error) {
+	data, err = rdb.R.Get(ctx, suggestionsKey(userID)).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// ClearSuggestions drops the cached suggestions of the users, e.g. after they
+// follow or block someone.
+func (rdb *RedisClient) ClearSuggestions(userIDs ...string) error {
+	keys := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		keys[i] = suggestionsKey(id)
+	}
+	return rdb.R.Del(ctx, keys...).Err()
+}
+
+// RateLimitResult is the state of a rate limit bucket after a request.
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
+
+// gcraScript is the generic cell rate algorithm, a token bucket kept as a
+// single "theoretical arrival time". Redis TIME is used so every replica
+// shares one clock.
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+
+local new_tat = tat + interval
+local allow_at = new_tat - burst * interval
+if allow_at > now then
+	return {0, 0, allow_at - now, tat - now}
+end
+
+redis.call('SET', KEYS[1], new_tat, 'PX', new_tat - now)
+return {1, math.floor((now - allow_at) / interval), 0, new_tat - now}
+`)
+
+// RateLimit takes a token from the bucket under key, which holds limit
+// tokens and refills completely over period.
+func (rdb *RedisClient) RateLimit(key string, limit int, period time.Duration) (*RateLimitResult, error) {
+	interval := period.Milliseconds() / int64(limit)
+	if interval < 1 {
+		interval = 1
+	}
+
+	values, err := gcraScript.Run(ctx, rdb.R, []string{key}, interval, limit).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RateLimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}